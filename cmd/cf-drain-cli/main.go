@@ -29,46 +29,99 @@ func (c CFDrainCLI) Run(conn plugin.CliConnection, args []string) {
 		log.Fatalf("Expected at least 1 argument, but got 0.")
 	}
 
-	ccCurler := cloudcontroller.NewCLICurlClient(conn)
-	sdClient := drain.NewServiceDrainLister(ccCurler)
+	args, dryRun := extractDryRun(args)
 	logger := newLogger(os.Stdout)
 	httpClient := &http.Client{
 		Timeout: 30 * time.Second,
 	}
-	downloader := command.NewGithubReleaseDownloader(httpClient, logger)
+	var downloader command.Downloader = command.NewGithubReleaseDownloader(
+		httpClient,
+		logger,
+		command.WithReleasesURL(os.Getenv("CF_DRAINS_RELEASES_URL")),
+		command.WithGithubToken(os.Getenv("CF_DRAINS_GITHUB_TOKEN")),
+		command.WithGithubComToken(os.Getenv("GITHUB_TOKEN")),
+		command.WithCacheDir(path.Join(cfHome(log), ".cf", "plugins", "drains")),
+	)
+	if dryRun {
+		conn = command.NewDryRunConnection(conn, logger)
+		downloader = command.NewDryRunDownloader(logger)
+	}
 
+	ccCurler := cloudcontroller.NewCLICurlClient(conn)
+	apiVersion := cloudcontroller.ProbeAPIVersion(ccCurler)
+	// The CLI captures the output of plugin commands in shared state, so
+	// curls through the CLI connection are kept sequential.
+	sdClient := drain.NewServiceDrainLister(ccCurler, drain.WithServiceDrainAPIVersion(apiVersion))
+	spaceLister := cloudcontroller.NewSpaceListerClient(ccCurler)
+	appLister := cloudcontroller.NewAppListerClient(ccCurler, apiVersion)
+	envFetcher := cloudcontroller.NewClient(ccCurler)
 	switch args[0] {
 	case "drain":
 		if len(args) < 3 {
 			c.exitWithUsage("drain")
 		}
 		command.CreateDrain(conn, args[1:], logger)
+	case "create-drain":
+		if len(args) < 3 {
+			c.exitWithUsage("create-drain")
+		}
+		command.CreateUnboundDrain(conn, args[1:], logger)
 	case "delete-drain":
 		if len(args) < 2 {
 			c.exitWithUsage("delete-drain")
 		}
 		command.DeleteDrain(conn, args[1:], logger, os.Stdin, sdClient)
+	case "update-drain":
+		if len(args) < 3 {
+			c.exitWithUsage("update-drain")
+		}
+		command.UpdateDrain(conn, args[1:], logger, sdClient)
 	case "bind-drain":
 		if len(args) < 3 {
 			c.exitWithUsage("bind-drain")
 		}
-		command.BindDrain(conn, sdClient, args[1:], logger)
+		command.BindDrain(conn, sdClient, appLister, args[1:], logger)
+	case "apply-drains":
+		if len(args) < 3 {
+			c.exitWithUsage("apply-drains")
+		}
+		command.ApplyDrains(conn, args[1:], logger, sdClient, appLister, envFetcher)
+	case "export-drains":
+		command.ExportDrains(conn, args[1:], logger, os.Stdout, sdClient)
+	case "unbind-drain":
+		if len(args) < 3 {
+			c.exitWithUsage("unbind-drain")
+		}
+		command.UnbindDrain(conn, sdClient, args[1:], logger)
 	case "drains":
-		command.Drains(conn, nil, logger, os.Stdout, sdClient)
+		command.Drains(conn, args[1:], logger, os.Stdout, spaceLister, sdClient)
 	case "drain-space":
 		if len(args) < 2 {
 			c.exitWithUsage("drain-space", "SYSLOG_DRAIN_URL required of the form syslog://destinaton.url:port")
 		}
 		tokenFetcher := command.NewTokenFetcher(configPath(log))
 		command.PushSpaceDrain(conn, args[1:], downloader, tokenFetcher, logger)
+	case "update-drain-space":
+		if len(args) < 2 {
+			c.exitWithUsage("update-drain-space")
+		}
+		tokenFetcher := command.NewTokenFetcher(configPath(log))
+		command.UpdateSpaceDrain(conn, args[1:], downloader, tokenFetcher, logger)
 	case "delete-drain-space":
 		if len(args) < 2 {
 			c.exitWithUsage("delete-drain-space")
 		}
 		command.DeleteSpaceDrain(conn, args[1:], logger, os.Stdin, sdClient, command.DeleteDrain)
+	case "drain-space-status":
+		if len(args) < 2 {
+			c.exitWithUsage("drain-space-status")
+		}
+		command.DrainSpaceStatus(conn, args[1:], logger, os.Stdout, sdClient, appLister, envFetcher, httpClient)
 	}
 }
 
+const dryRunUsage = "Print the CF commands that would run, with secrets redacted, without changing anything."
+
 // version is set via ldflags at compile time. It should be JSON encoded
 // plugin.VersionType. If it does not unmarshal, the plugin version will be
 // left empty.
@@ -88,34 +141,104 @@ func (c CFDrainCLI) GetMetadata() plugin.PluginMetadata {
 				Name:     "drains",
 				HelpText: "Lists all services for syslog drains.",
 				UsageDetails: plugin.Usage{
-					Usage: "drains",
+					Usage: "drains [--output FORMAT] [--org ORG | --all-spaces]",
+					Options: map[string]string{
+						"-output":     "Output format for the drains (table, json, csv). Default is table.",
+						"-org":        "List drains in every space of the given org.",
+						"-all-spaces": "List drains in every space the user can see.",
+					},
 				},
 			},
 			{
 				Name:     "drain",
 				HelpText: "Creates a user provided service for syslog drains and binds it to a given application.",
 				UsageDetails: plugin.Usage{
-					Usage: "drain APP_NAME SYSLOG_DRAIN_URL [--drain-name NAME] [--type TYPE]",
+					Usage: "drain APP_NAME SYSLOG_DRAIN_URL [--drain-name NAME] [--type TYPE] [--dry-run]",
 					Options: map[string]string{
 						"-drain-name": "The name of the drain that will be created. If excluded, the drain name will be `cf-drain-UUID`.",
 						"-type":       "The type of logs to be sent to the syslog drain. Available types: `logs`, `metrics`, and `all`. Default is `logs`",
+						"-dry-run":    dryRunUsage,
+					},
+				},
+			},
+			{
+				Name:     "create-drain",
+				HelpText: "Creates a user provided service for syslog drains without binding it to any application.",
+				UsageDetails: plugin.Usage{
+					Usage: "create-drain DRAIN_NAME SYSLOG_DRAIN_URL [--type TYPE] [--dry-run]",
+					Options: map[string]string{
+						"-type":    "The type of logs to be sent to the syslog drain. Available types: `logs`, `metrics`, and `all`. Default is `logs`",
+						"-dry-run": dryRunUsage,
+					},
+				},
+			},
+			{
+				Name:     "update-drain",
+				HelpText: "Updates the URL or type of an existing syslog drain without unbinding its applications.",
+				UsageDetails: plugin.Usage{
+					Usage: "update-drain DRAIN_NAME [--url URL] [--type TYPE] [--dry-run]",
+					Options: map[string]string{
+						"-url":     "The new syslog drain URL.",
+						"-type":    "The type of logs to be sent to the syslog drain. Available types: `logs`, `metrics`, and `all`. Default is the current type.",
+						"-dry-run": dryRunUsage,
 					},
 				},
 			},
 			{
 				Name:     "bind-drain",
-				HelpText: "Binds an application to an existing syslog drain.",
+				HelpText: "Binds applications to an existing syslog drain.",
+				UsageDetails: plugin.Usage{
+					Usage: "bind-drain [APP_NAME...] [--apps PATTERN] [--selector SELECTOR] DRAIN_NAME [--dry-run]",
+					Options: map[string]string{
+						"-apps":     "Bind every application whose name matches the glob pattern, e.g. 'payments-*'.",
+						"-selector": "Bind every application matching the label selector, e.g. team=payments. Requires the v3 cloud controller API.",
+						"-dry-run":  dryRunUsage,
+					},
+				},
+			},
+			{
+				Name:     "unbind-drain",
+				HelpText: "Unbinds an application from a syslog drain without deleting the drain.",
 				UsageDetails: plugin.Usage{
-					Usage: "bind-drain APP_NAME DRAIN_NAME",
+					Usage: "unbind-drain APP_NAME DRAIN_NAME [--dry-run]",
+					Options: map[string]string{
+						"-dry-run": dryRunUsage,
+					},
 				},
 			},
 			{
 				Name:     "delete-drain",
 				HelpText: "Unbinds the service from applications and deletes the service.",
 				UsageDetails: plugin.Usage{
-					Usage: "delete-drain DRAIN_NAME [--force]",
+					Usage: "delete-drain DRAIN_NAME [--force] [--dry-run]",
+					Options: map[string]string{
+						"-force":   "Skip warning prompt. Default is false.",
+						"-dry-run": dryRunUsage,
+					},
+				},
+			},
+			{
+				Name:     "apply-drains",
+				HelpText: "Creates, updates and binds drains in the current space to match a manifest.",
+				UsageDetails: plugin.Usage{
+					Usage: "apply-drains -f FILE [--var NAME=VALUE]... [--vars-file FILE] [--prune] [--dry-run]",
+					Options: map[string]string{
+						"f":          "Path to the drain manifest.",
+						"-var":       "Value for a ((NAME)) placeholder in the drain URLs. Can be given more than once.",
+						"-vars-file": "Path to a YAML file with values for the placeholders in the drain URLs.",
+						"-prune":     "Delete drains that are not in the manifest, except drains managed by a space drain. Default is false.",
+						"-dry-run":   dryRunUsage,
+					},
+				},
+			},
+			{
+				Name:     "export-drains",
+				HelpText: "Writes the drains in the current space as a manifest for apply-drains.",
+				UsageDetails: plugin.Usage{
+					Usage: "export-drains [--output FORMAT] [--include-secrets]",
 					Options: map[string]string{
-						"-force": "Skip warning prompt. Default is false.",
+						"-output":          "Output format for the manifest (yaml, json). Default is yaml.",
+						"-include-secrets": "Include credentials in the drain URLs instead of placeholders. Default is false.",
 					},
 				},
 			},
@@ -123,11 +246,40 @@ func (c CFDrainCLI) GetMetadata() plugin.PluginMetadata {
 				Name:     "drain-space",
 				HelpText: "Pushes app to bind all apps in the space to the configured syslog drain.",
 				UsageDetails: plugin.Usage{
-					Usage: "drain-space SYSLOG_DRAIN_URL [--drain-name NAME] [--path PATH] [--type TYPE]",
+					Usage: "drain-space SYSLOG_DRAIN_URL [--drain-name NAME] [--path PATH | --version VERSION | --pre-release] [--skip-checksum] [--type TYPE] [--client-id ID --client-secret SECRET] [--selector SELECTOR] [--interval DURATION] [--jitter DURATION] [--max-backoff DURATION] [--dry-run]",
 					Options: map[string]string{
-						"-drain-name": "Name for the space drain.",
-						"-path":       "Path to the space drain app to push. If omitted the latest release will be downloaded.",
-						"-type":       "Which log type to filter on (logs, metrics, all). Default is all.",
+						"-drain-name":    "Name for the space drain.",
+						"-path":          "Path to the space drain app to push. If omitted the latest release will be downloaded.",
+						"-version":       "Release of the space drain app to download, e.g. v1.2.3. Default is the latest release.",
+						"-pre-release":   "Download the latest release even if it is a pre-release.",
+						"-skip-checksum": "Use a release that has no checksums.txt without verifying it. Not recommended.",
+						"-client-id":     "UAA client the space drain authenticates as. Recommended over the default of using your refresh token.",
+						"-client-secret": "Secret of the UAA client given with --client-id.",
+						"-type":          "Which log type to filter on (logs, metrics, all). Default is all.",
+						"-selector":      "Only bind apps whose labels match the label selector, e.g. env=prod. Apps that stop matching are unbound. Requires the v3 cloud controller API.",
+						"-interval":      "Time between full reconciles of the space, e.g. 5m. Default is 10m with the v3 cloud controller API and 1m otherwise.",
+						"-jitter":        "Largest random delay added to each full reconcile. Default is 10s.",
+						"-max-backoff":   "Longest wait between retries of a failed reconcile. Default is 5m.",
+						"-dry-run":       dryRunUsage,
+					},
+				},
+			},
+			{
+				Name:     "update-drain-space",
+				HelpText: "Updates the syslog drain URL, type or version of an existing space drain and restarts it. Apps stay bound to the drain.",
+				UsageDetails: plugin.Usage{
+					Usage: "update-drain-space DRAIN_NAME [--url SYSLOG_DRAIN_URL] [--type TYPE] [--path PATH | --version VERSION | --upgrade [--pre-release]] [--skip-checksum] [--client-id ID --client-secret SECRET] [--dry-run]",
+					Options: map[string]string{
+						"-url":           "The new syslog drain URL.",
+						"-type":          "The new type of logs to be sent to the syslog drain. Available types: `logs`, `metrics`, and `all`.",
+						"-path":          "Push the space drain app from the given directory.",
+						"-upgrade":       "Push the latest space drain app from github.",
+						"-version":       "Push the given release of the space drain app from github, e.g. v1.2.3.",
+						"-pre-release":   "Upgrade to the latest release even if it is a pre-release.",
+						"-skip-checksum": "Use a release that has no checksums.txt without verifying it. Not recommended.",
+						"-client-id":     "Switch the space drain to authenticate as the given UAA client.",
+						"-client-secret": "Secret of the UAA client given with --client-id.",
+						"-dry-run":       dryRunUsage,
 					},
 				},
 			},
@@ -135,16 +287,40 @@ func (c CFDrainCLI) GetMetadata() plugin.PluginMetadata {
 				Name:     "delete-drain-space",
 				HelpText: "Deletes space drain app and unbinds all the apps in the space from the configured syslog drain.",
 				UsageDetails: plugin.Usage{
-					Usage: "delete-drain-space DRAIN_NAME [--force]",
+					Usage: "delete-drain-space DRAIN_NAME [--force] [--dry-run]",
 					Options: map[string]string{
-						"-force": "Skip warning prompt. Default is false.",
+						"-force":   "Skip warning prompt. Default is false.",
+						"-dry-run": dryRunUsage,
 					},
 				},
 			},
+			{
+				Name:     "drain-space-status",
+				HelpText: "Shows the configuration of a space drain and which apps in the space it covers.",
+				UsageDetails: plugin.Usage{
+					Usage: "drain-space-status DRAIN_NAME",
+				},
+			},
 		},
 	}
 }
 
+// extractDryRun removes the global --dry-run flag from the arguments and
+// reports whether it was given.
+func extractDryRun(args []string) ([]string, bool) {
+	var rest []string
+	var dryRun bool
+	for _, a := range args {
+		if a == "--dry-run" {
+			dryRun = true
+			continue
+		}
+		rest = append(rest, a)
+	}
+
+	return rest, dryRun
+}
+
 func (c CFDrainCLI) exitWithUsage(cmdName string, helpMsg ...string) {
 	i := c.indexOfCommand(cmdName)
 	fmt.Printf("\nInvalid arguments passed to %s command.", cmdName)
@@ -213,15 +389,20 @@ func (l *logger) Print(a ...interface{}) {
 }
 
 func configPath(log *log.Logger) string {
+	return path.Join(cfHome(log), ".cf", "config.json")
+}
+
+// cfHome returns the directory the CF CLI keeps its .cf directory in.
+func cfHome(log *log.Logger) string {
 	if cfHome := os.Getenv("CF_HOME"); cfHome != "" {
-		return path.Join(cfHome, ".cf", "config.json")
+		return cfHome
 	}
 
 	usr, err := user.Current()
 	if err != nil {
 		log.Fatal(err)
 	}
-	return path.Join(usr.HomeDir, ".cf", "config.json")
+	return usr.HomeDir
 }
 
 func groupProvider() string {
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"log"
 	"os"
+	"time"
 
 	envstruct "code.cloudfoundry.org/go-envstruct"
 )
@@ -15,12 +16,40 @@ type Config struct {
 	DrainURL  string `env:"DRAIN_URL, required"`
 	DrainType string `env:"DRAIN_TYPE"`
 
+	// DrainSelector is a label selector. When it is set only the apps that
+	// match it are bound to the drain.
+	DrainSelector string `env:"DRAIN_SELECTOR"`
+
 	APIAddr  string `env:"API_ADDR, required"`
 	UAAAddr  string `env:"UAA_ADDR, required"`
 	ClientID string `env:"CLIENT_ID, required"`
+	// ClientSecret authenticates as the UAA client with the client
+	// credentials grant. It is preferred over RefreshToken, which ties the
+	// space drain to the user that pushed it.
+	ClientSecret string `env:"CLIENT_SECRET"`
 
 	SkipCertVerify bool `env:"SKIP_CERT_VERIFY"`
 
+	// SyncInterval is the time between full reconciles. When it is not set
+	// it is ten minutes if app events are available and one minute if not.
+	SyncInterval time.Duration `env:"SYNC_INTERVAL"`
+	// SyncJitter is the largest random delay added to each full reconcile so
+	// that space drains on a foundation do not all sync at the same time.
+	SyncJitter time.Duration `env:"SYNC_JITTER"`
+	// BackoffInitial and BackoffMax bound the exponential backoff used to
+	// retry failed reconciles and event polls.
+	BackoffInitial time.Duration `env:"BACKOFF_INITIAL"`
+	BackoffMax     time.Duration `env:"BACKOFF_MAX"`
+
+	// HealthFailureThreshold is the number of consecutive failed reconciles
+	// after which /health reports the space drain as unhealthy.
+	HealthFailureThreshold int `env:"HEALTH_FAILURE_THRESHOLD"`
+
+	// StatusToken enables /status and /metrics. Requests to them must send
+	// it as a bearer token. They are not served without it because the
+	// app's route is public.
+	StatusToken string `env:"STATUS_TOKEN"`
+
 	VCAPApplication Application
 	RefreshToken    string `env:"REFRESH_TOKEN"`
 }
@@ -31,7 +60,12 @@ type Application struct {
 
 func loadConfig() Config {
 	cfg := Config{
-		DrainType: "all",
+		DrainType:      "all",
+		SyncJitter:     10 * time.Second,
+		BackoffInitial: 5 * time.Second,
+		BackoffMax:     5 * time.Minute,
+
+		HealthFailureThreshold: 5,
 	}
 	if err := envstruct.Load(&cfg); err != nil {
 		log.Fatal(err)
@@ -47,5 +81,21 @@ func loadConfig() Config {
 
 	cfg.VCAPApplication = app
 
+	if cfg.ClientSecret == "" && cfg.RefreshToken == "" {
+		log.Fatal("CLIENT_SECRET or REFRESH_TOKEN must be set")
+	}
+
+	if cfg.SyncInterval < 0 || cfg.SyncJitter < 0 {
+		log.Fatal("SYNC_INTERVAL and SYNC_JITTER must not be negative")
+	}
+
+	if cfg.HealthFailureThreshold < 1 {
+		log.Fatal("HEALTH_FAILURE_THRESHOLD must be at least 1")
+	}
+
+	if cfg.BackoffInitial <= 0 || cfg.BackoffMax < cfg.BackoffInitial {
+		log.Fatal("BACKOFF_INITIAL must be positive and no larger than BACKOFF_MAX")
+	}
+
 	return cfg
 }
@@ -4,6 +4,7 @@ import (
 	"crypto/tls"
 	"fmt"
 	"log"
+	"math/rand"
 	"net/http"
 	"os"
 	"time"
@@ -17,6 +18,7 @@ var version string
 
 func main() {
 	log := log.New(os.Stderr, "", log.LstdFlags)
+	rand.Seed(time.Now().UnixNano())
 	log.Printf("starting space drain...")
 	defer log.Printf("space drain closing...")
 
@@ -47,125 +49,94 @@ func main() {
 		cfg.RefreshToken,
 		cfg.VCAPApplication.ID,
 		cfg.SkipCertVerify,
-		log,
+		cloudcontroller.WithClientSecret(cfg.ClientSecret),
 	)
+	if cfg.ClientSecret != "" {
+		log.Printf("using client credentials of %s", cfg.ClientID)
+	} else {
+		log.Printf("using a refresh token, set CLIENT_SECRET to use client credentials instead")
+	}
 
-	curler := cloudcontroller.NewHTTPCurlClient(cfg.APIAddr, httpClient, tokenManager, saveAndRestager)
+	metrics := newSpaceDrainMetrics()
+	status := newStatus(cfg.HealthFailureThreshold, cfg.DrainURL, cfg.DrainType)
+	tokenFetcher := tokenHealth{
+		TokenFetcher: tokenManager,
+		status:       status,
+		metrics:      metrics,
+	}
+
+	curler := cloudcontroller.NewHTTPCurlClient(
+		cfg.APIAddr,
+		instrumentedDoer{d: httpClient, m: metrics},
+		tokenFetcher,
+		saveAndRestager,
+	)
 	restager = cloudcontroller.NewRestager(
 		cfg.VCAPApplication.ID,
 		curler,
 		log,
 	)
 
-	drainLister := drain.NewServiceDrainLister(curler)
-	drainCreator := cloudcontroller.NewCreateDrainClient(curler)
-	drainBinder := cloudcontroller.NewBindDrainClient(curler)
-	appLister := cloudcontroller.NewAppListerClient(curler)
-
-	createAndBind(drainLister, drainCreator, drainBinder, appLister, curler, cfg, log)
-	go func() {
-		for range time.Tick(time.Minute) {
-			createAndBind(drainLister, drainCreator, drainBinder, appLister, curler, cfg, log)
-		}
-	}()
-
-	http.HandleFunc("/version", func(w http.ResponseWriter, r *http.Request) {
-		w.Write([]byte(fmt.Sprintf(`{"version": "%s"}`, version)))
-	})
-	http.ListenAndServe(":"+os.Getenv("PORT"), nil)
-}
+	apiVersion := cloudcontroller.ProbeAPIVersion(curler)
+	log.Printf("using %s cloud controller API for drains", apiVersion)
 
-func createAndBind(
-	drainLister *drain.ServiceDrainLister,
-	drainCreator *cloudcontroller.CreateDrainClient,
-	drainBinder *cloudcontroller.BindDrainClient,
-	appLister *cloudcontroller.AppListerClient,
-	curler cloudcontroller.Curler,
-	cfg Config,
-	log *log.Logger,
-) {
-	drains, err := drainLister.Drains(cfg.SpaceID)
-	if err != nil {
-		log.Printf("failed to fetch drains: %s", err)
-		return
+	drainLister := drain.NewServiceDrainLister(
+		curler,
+		drain.WithServiceDrainAPIVersion(apiVersion),
+		drain.WithServiceDrainConcurrency(4, 10),
+	)
+	rec := &reconciler{
+		drainLister:  drainLister,
+		drainCreator: cloudcontroller.NewCreateDrainClient(curler, apiVersion),
+		drainUpdater: cloudcontroller.NewUpdateDrainClient(curler, apiVersion),
+		drainBinder:  cloudcontroller.NewBindDrainClient(curler, apiVersion),
+		appLister:    cloudcontroller.NewAppListerClient(curler, apiVersion),
+		envFetcher:   cloudcontroller.NewClient(curler),
+		status:       status,
+		metrics:      metrics,
+		cfg:          cfg,
+		log:          log,
 	}
 
-	drain, ok := hasDrain(cfg.DrainName, drains)
-	if !ok {
-		log.Printf("creating %s drain...", cfg.DrainName)
-		if err := drainCreator.CreateDrain(
-			cfg.DrainName,
-			cfg.DrainURL,
+	// Audit events are only available with the v3 API. Without them every
+	// reconcile is a full one.
+	var cursor eventCursor
+	resyncInterval := time.Minute
+	if apiVersion == cloudcontroller.V3 {
+		// Start a little in the past to allow for clock skew with the CC.
+		// Reconciling an app twice is harmless.
+		cursor = cloudcontroller.NewAuditEventCursor(
+			cloudcontroller.NewAuditEventClient(curler),
 			cfg.SpaceID,
-			cfg.DrainType,
-		); err != nil {
-			log.Printf("failed to create drain: %s", err)
-			return
-		}
-		log.Printf("created %s drain", cfg.DrainName)
-
-		// go again so that ListDrains can find it and get its guid.
-		createAndBind(drainLister, drainCreator, drainBinder, appLister, curler, cfg, log)
-		return
-	}
-
-	apps, err := appLister.ListApps(cfg.SpaceID)
-	if err != nil {
-		log.Printf("failed to list apps: %s", err)
-		return
+			time.Now().Add(-time.Minute),
+		)
+		resyncInterval = 10 * time.Minute
 	}
-
-	log.Printf("binding %d apps to drain...", len(apps))
-	for _, app := range apps {
-		if isSpaceDrain(curler, app.Guid, log) {
-			continue
-		}
-
-		if containsApp(app.Guid, drain.AppGuids) || app.Guid == cfg.VCAPApplication.ID {
-			continue
-		}
-
-		if err := drainBinder.BindDrain(app.Guid, drain.Guid); err != nil {
-			log.Printf("failed to bind %s to drain: %s", app.Guid, err)
-			continue
-		}
-		drain.AppGuids = append(drain.AppGuids, app.Guid)
-	}
-	log.Printf("done binding apps to drain.")
-}
-
-func containsApp(appGuid string, guids []string) bool {
-	for _, g := range guids {
-		if g == appGuid {
-			return true
-		}
-	}
-
-	return false
-}
-
-func isSpaceDrain(curler cloudcontroller.Curler, appGUID string, log *log.Logger) bool {
-	c := cloudcontroller.NewClient(curler)
-
-	envs, err := c.EnvVars(appGUID)
-	if err != nil {
-		log.Printf("failed to read env variables for %s: %s", appGUID, err)
-		return true
+	if cfg.SyncInterval != 0 {
+		resyncInterval = cfg.SyncInterval
 	}
 
-	if envs["DRAIN_SCOPE"] == "space" {
-		return true
+	s := &scheduler{
+		rec:            rec,
+		cursor:         cursor,
+		pollInterval:   eventPollInterval,
+		resyncInterval: resyncInterval,
+		jitter:         cfg.SyncJitter,
+		backoff:        backoff{initial: cfg.BackoffInitial, max: cfg.BackoffMax},
+		log:            log,
+		after:          time.After,
 	}
-
-	return false
-}
-
-func hasDrain(name string, drains []drain.Drain) (drain.Drain, bool) {
-	for _, drain := range drains {
-		if drain.Name == name {
-			return drain, true
-		}
+	go s.run()
+
+	http.HandleFunc("/health", status.healthHandler)
+	if cfg.StatusToken != "" {
+		http.Handle("/status", requireToken(cfg.StatusToken, http.HandlerFunc(status.statusHandler)))
+		http.Handle("/metrics", requireToken(cfg.StatusToken, metrics.registry))
+	} else {
+		log.Printf("not serving /status and /metrics, set STATUS_TOKEN to enable them")
 	}
-
-	return drain.Drain{}, false
+	http.HandleFunc("/version", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(fmt.Sprintf(`{"version": "%s"}`, version)))
+	})
+	http.ListenAndServe(":"+os.Getenv("PORT"), nil)
 }
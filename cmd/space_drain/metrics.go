@@ -0,0 +1,124 @@
+package main
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/cf-drain-cli/internal/cloudcontroller"
+	"code.cloudfoundry.org/cf-drain-cli/internal/metrics"
+)
+
+var durationBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// spaceDrainMetrics are the metrics served on /metrics.
+type spaceDrainMetrics struct {
+	registry *metrics.Registry
+
+	reconcileDuration *metrics.Histogram
+	ccRequests        *metrics.Counter
+	ccRequestDuration *metrics.Histogram
+	binds             *metrics.Counter
+	unbinds           *metrics.Counter
+	tokenRefreshes    *metrics.Counter
+	appsBound         *metrics.Gauge
+	appsInSpace       *metrics.Gauge
+}
+
+func newSpaceDrainMetrics() *spaceDrainMetrics {
+	r := metrics.NewRegistry()
+
+	return &spaceDrainMetrics{
+		registry: r,
+
+		reconcileDuration: r.NewHistogram(
+			"space_drain_reconcile_duration_seconds",
+			"Duration of reconciles by kind (full or partial).",
+			durationBuckets,
+			"kind",
+		),
+		ccRequests: r.NewCounter(
+			"space_drain_cc_requests_total",
+			"Cloud controller requests by endpoint, method and status code.",
+			"endpoint", "method", "status",
+		),
+		ccRequestDuration: r.NewHistogram(
+			"space_drain_cc_request_duration_seconds",
+			"Latency of cloud controller requests by endpoint, method and status code.",
+			durationBuckets,
+			"endpoint", "method", "status",
+		),
+		binds: r.NewCounter(
+			"space_drain_binds_total",
+			"Apps bound to the drain by result (success or failure).",
+			"result",
+		),
+		unbinds: r.NewCounter(
+			"space_drain_unbinds_total",
+			"Apps unbound from the drain by result (success or failure).",
+			"result",
+		),
+		tokenRefreshes: r.NewCounter(
+			"space_drain_token_refreshes_total",
+			"Access token refreshes by result (success or failure).",
+			"result",
+		),
+		appsBound: r.NewGauge(
+			"space_drain_apps_bound",
+			"Apps bound to the drain after the last reconcile.",
+		),
+		appsInSpace: r.NewGauge(
+			"space_drain_apps_in_space",
+			"Apps in the space that match the drain selector at the last full reconcile.",
+		),
+	}
+}
+
+func result(err error) string {
+	if err != nil {
+		return "failure"
+	}
+
+	return "success"
+}
+
+// instrumentedDoer records the count and latency of cloud controller
+// requests.
+type instrumentedDoer struct {
+	d cloudcontroller.Doer
+	m *spaceDrainMetrics
+}
+
+func (d instrumentedDoer) Do(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := d.d.Do(req)
+	duration := time.Since(start)
+
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(resp.StatusCode)
+	}
+
+	endpoint := ccEndpoint(req.URL.Path)
+	d.m.ccRequests.Inc(endpoint, req.Method, status)
+	d.m.ccRequestDuration.Observe(duration.Seconds(), endpoint, req.Method, status)
+
+	return resp, err
+}
+
+var guidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// ccEndpoint replaces the guids in a request path so that requests for
+// different resources are counted together.
+func ccEndpoint(path string) string {
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		if guidPattern.MatchString(s) {
+			segments[i] = ":guid"
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
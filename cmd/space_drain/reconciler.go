@@ -0,0 +1,327 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"code.cloudfoundry.org/cf-drain-cli/internal/cloudcontroller"
+	"code.cloudfoundry.org/cf-drain-cli/internal/drain"
+)
+
+type drainLister interface {
+	Drains(spaceGuid string) ([]drain.Drain, error)
+}
+
+type drainCreator interface {
+	CreateDrain(name, url, spaceGuid, drainType string) error
+}
+
+type drainUpdater interface {
+	UpdateDrain(serviceInstanceGuid, url, drainType string) error
+}
+
+type drainBinder interface {
+	BindDrain(appGuid, serviceInstanceGuid string) error
+	UnbindDrain(appGuid, serviceInstanceGuid string) error
+}
+
+type appLister interface {
+	ListAppsWithSelector(spaceGuid, selector string) ([]cloudcontroller.App, error)
+	ListAppsByGuid(spaceGuid, selector string, guids []string) ([]cloudcontroller.App, error)
+}
+
+type envFetcher interface {
+	EnvVars(appGuid string) (map[string]string, error)
+}
+
+// reconciler converges the space drain's service instance and its bindings
+// to the configuration of the space drain app.
+type reconciler struct {
+	drainLister  drainLister
+	drainCreator drainCreator
+	drainUpdater drainUpdater
+	drainBinder  drainBinder
+	appLister    appLister
+	envFetcher   envFetcher
+	status       *status
+	metrics      *spaceDrainMetrics
+	cfg          Config
+	log          *log.Logger
+}
+
+// reconcileSummary records what a reconcile cycle changed.
+type reconcileSummary struct {
+	Added   int
+	Removed int
+	Updated int
+	Failed  int
+
+	// AppsBound is the number of apps bound to the drain afterwards.
+	AppsBound int
+	// AppsInSpace is the number of apps that were considered.
+	AppsInSpace int
+	// BoundApps are the names of the apps that are bound as they should be.
+	BoundApps []string
+	// FailedApps maps the names of the apps that could not be bound or
+	// unbound to the error.
+	FailedApps map[string]string
+}
+
+// reconcile converges every app in the space.
+func (r *reconciler) reconcile() error {
+	start := time.Now()
+	summary, err := r.reconcileDrain(nil)
+	r.metrics.reconcileDuration.Observe(time.Since(start).Seconds(), "full")
+	if err == nil {
+		r.metrics.appsInSpace.Set(float64(summary.AppsInSpace))
+	}
+
+	r.record(summary, true, err)
+	return r.logSummary(summary, err)
+}
+
+// reconcileApps converges only the given apps. Bindings of other apps are
+// left for the next full reconcile.
+func (r *reconciler) reconcileApps(appGuids []string) error {
+	start := time.Now()
+	summary, err := r.reconcileDrain(appGuids)
+	r.metrics.reconcileDuration.Observe(time.Since(start).Seconds(), "partial")
+
+	r.record(summary, false, err)
+	return r.logSummary(summary, err)
+}
+
+func (r *reconciler) record(summary reconcileSummary, full bool, err error) {
+	r.status.recordReconcile(summary, full, err)
+	if err == nil {
+		r.metrics.appsBound.Set(float64(summary.AppsBound))
+	}
+}
+
+func (r *reconciler) logSummary(summary reconcileSummary, err error) error {
+	if err != nil {
+		r.log.Printf("failed to reconcile drain: %s", err)
+		return err
+	}
+
+	r.log.Printf(
+		"reconciled drain: added %d, removed %d, updated %d, failed %d",
+		summary.Added,
+		summary.Removed,
+		summary.Updated,
+		summary.Failed,
+	)
+
+	return nil
+}
+
+// reconcileDrain converges the drain and the bindings of the given apps, or
+// of every app in the space if appGuids is nil.
+func (r *reconciler) reconcileDrain(appGuids []string) (reconcileSummary, error) {
+	summary := reconcileSummary{
+		FailedApps: make(map[string]string),
+	}
+
+	d, err := r.drain(&summary)
+	if err != nil {
+		return summary, err
+	}
+
+	apps, seen, err := r.apps(appGuids)
+	if err != nil {
+		return summary, err
+	}
+
+	desired, unknown := r.desiredApps(apps)
+
+	appsBound := len(d.AppGuids)
+	for _, app := range apps {
+		if !desired[app.Guid] {
+			continue
+		}
+
+		if containsApp(app.Guid, d.AppGuids) {
+			summary.BoundApps = append(summary.BoundApps, app.Name)
+			continue
+		}
+
+		r.log.Printf("binding %s to drain...", app.Name)
+		err := r.drainBinder.BindDrain(app.Guid, d.Guid)
+		r.metrics.binds.Inc(result(err))
+		if err != nil {
+			r.log.Printf("failed to bind %s to drain: %s", app.Name, err)
+			summary.Failed++
+			summary.FailedApps[app.Name] = err.Error()
+			continue
+		}
+		summary.Added++
+		summary.BoundApps = append(summary.BoundApps, app.Name)
+		appsBound++
+	}
+
+	for _, appGuid := range d.AppGuids {
+		if desired[appGuid] || unknown[appGuid] {
+			continue
+		}
+
+		// A partial reconcile only sees the apps that changed. Bindings of
+		// other apps are left for the next full reconcile.
+		if appGuids != nil && !containsAppGuid(appGuid, seen) {
+			continue
+		}
+
+		r.log.Printf("unbinding %s from drain...", appGuid)
+		err := r.drainBinder.UnbindDrain(appGuid, d.Guid)
+		r.metrics.unbinds.Inc(result(err))
+		if err != nil {
+			r.log.Printf("failed to unbind %s from drain: %s", appGuid, err)
+			summary.Failed++
+			summary.FailedApps[appName(appGuid, seen)] = err.Error()
+			continue
+		}
+		summary.Removed++
+		appsBound--
+	}
+	summary.AppsBound = appsBound
+	summary.AppsInSpace = len(apps)
+
+	return summary, nil
+}
+
+// apps returns the apps that match the drain selector and the apps whose
+// bindings can be decided. For a full reconcile these are the same: every
+// bound app that does not match the selector is unbound. A partial reconcile
+// also lists the given apps without the selector so that an app whose labels
+// stopped matching is unbound.
+func (r *reconciler) apps(appGuids []string) (apps, seen []cloudcontroller.App, err error) {
+	if appGuids == nil {
+		apps, err = r.appLister.ListAppsWithSelector(r.cfg.SpaceID, r.cfg.DrainSelector)
+		return apps, apps, err
+	}
+
+	apps, err = r.appLister.ListAppsByGuid(r.cfg.SpaceID, r.cfg.DrainSelector, appGuids)
+	if err != nil || r.cfg.DrainSelector == "" {
+		return apps, apps, err
+	}
+
+	seen, err = r.appLister.ListAppsByGuid(r.cfg.SpaceID, "", appGuids)
+	return apps, seen, err
+}
+
+// drain returns the space drain's service instance. It is created if it does
+// not exist and its URL is updated if it differs from the configuration.
+func (r *reconciler) drain(summary *reconcileSummary) (drain.Drain, error) {
+	drains, err := r.drainLister.Drains(r.cfg.SpaceID)
+	if err != nil {
+		return drain.Drain{}, err
+	}
+
+	d, ok := hasDrain(r.cfg.DrainName, drains)
+	if !ok {
+		r.log.Printf("creating %s drain...", r.cfg.DrainName)
+		if err := r.drainCreator.CreateDrain(
+			r.cfg.DrainName,
+			r.cfg.DrainURL,
+			r.cfg.SpaceID,
+			r.cfg.DrainType,
+		); err != nil {
+			return drain.Drain{}, err
+		}
+		r.log.Printf("created %s drain", r.cfg.DrainName)
+		summary.Added++
+
+		// List again so that the drain's guid is known.
+		drains, err = r.drainLister.Drains(r.cfg.SpaceID)
+		if err != nil {
+			return drain.Drain{}, err
+		}
+
+		d, ok = hasDrain(r.cfg.DrainName, drains)
+		if !ok {
+			return drain.Drain{}, fmt.Errorf("created drain %s was not found", r.cfg.DrainName)
+		}
+
+		return d, nil
+	}
+
+	if d.DrainURL != cloudcontroller.DrainURLWithType(r.cfg.DrainURL, r.cfg.DrainType) {
+		r.log.Printf("updating %s drain URL and type...", r.cfg.DrainName)
+		if err := r.drainUpdater.UpdateDrain(d.Guid, r.cfg.DrainURL, r.cfg.DrainType); err != nil {
+			return drain.Drain{}, err
+		}
+		summary.Updated++
+	}
+
+	return d, nil
+}
+
+// desiredApps returns the guids of the apps that should be bound to the
+// drain. Apps whose environment could not be read are returned as unknown so
+// that their bindings are left alone.
+func (r *reconciler) desiredApps(apps []cloudcontroller.App) (desired, unknown map[string]bool) {
+	desired = make(map[string]bool)
+	unknown = make(map[string]bool)
+	for _, app := range apps {
+		if app.Guid == r.cfg.VCAPApplication.ID {
+			continue
+		}
+
+		envs, err := r.envFetcher.EnvVars(app.Guid)
+		if err != nil {
+			r.log.Printf("failed to read env variables for %s: %s", app.Guid, err)
+			unknown[app.Guid] = true
+			continue
+		}
+
+		if envs["DRAIN_SCOPE"] == "space" || drain.IsExcluded(app.Annotations, envs, r.cfg.DrainName) {
+			continue
+		}
+
+		desired[app.Guid] = true
+	}
+
+	return desired, unknown
+}
+
+// appName returns the name of the app with the given guid, or the guid if
+// the app is not known.
+func appName(appGuid string, apps []cloudcontroller.App) string {
+	for _, app := range apps {
+		if app.Guid == appGuid {
+			return app.Name
+		}
+	}
+
+	return appGuid
+}
+
+func containsAppGuid(appGuid string, apps []cloudcontroller.App) bool {
+	for _, app := range apps {
+		if app.Guid == appGuid {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsApp(appGuid string, guids []string) bool {
+	for _, g := range guids {
+		if g == appGuid {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasDrain(name string, drains []drain.Drain) (drain.Drain, bool) {
+	for _, drain := range drains {
+		if drain.Name == name {
+			return drain, true
+		}
+	}
+
+	return drain.Drain{}, false
+}
@@ -0,0 +1,352 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"code.cloudfoundry.org/cf-drain-cli/internal/cloudcontroller"
+	"code.cloudfoundry.org/cf-drain-cli/internal/drain"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Reconciler", func() {
+	var (
+		drainLister *stubDrainLister
+		drainClient *stubDrainClient
+		appLister   *stubAppLister
+		envFetcher  *stubEnvFetcher
+		logs        *bytes.Buffer
+		st          *status
+		r           *reconciler
+	)
+
+	BeforeEach(func() {
+		drainLister = &stubDrainLister{
+			drains: []drain.Drain{
+				{
+					Name:     "space-drain",
+					Guid:     "drain-guid",
+					DrainURL: "syslog://drain.example.com?drain-type=all",
+				},
+			},
+		}
+		drainClient = newStubDrainClient(drainLister)
+		appLister = &stubAppLister{
+			apps: []cloudcontroller.App{
+				{Name: "app-1", Guid: "app-1-guid"},
+				{Name: "app-2", Guid: "app-2-guid"},
+				{Name: "space-drain-app", Guid: "space-drain-app-guid"},
+			},
+		}
+		envFetcher = newStubEnvFetcher()
+		logs = &bytes.Buffer{}
+		st = newStatus(1, "syslog://drain.example.com", "all")
+
+		r = &reconciler{
+			drainLister:  drainLister,
+			drainCreator: drainClient,
+			drainUpdater: drainClient,
+			drainBinder:  drainClient,
+			appLister:    appLister,
+			envFetcher:   envFetcher,
+			status:       st,
+			metrics:      newSpaceDrainMetrics(),
+			cfg: Config{
+				SpaceID:         "space-guid",
+				DrainName:       "space-drain",
+				DrainURL:        "syslog://drain.example.com",
+				DrainType:       "all",
+				DrainSelector:   "team=payments",
+				VCAPApplication: Application{ID: "space-drain-app-guid"},
+			},
+			log: log.New(logs, "", 0),
+		}
+	})
+
+	It("binds every app in the space except itself", func() {
+		Expect(r.reconcile()).To(Succeed())
+
+		Expect(appLister.selectors).To(Equal([]string{"team=payments"}))
+		Expect(drainClient.bound).To(Equal([]string{"app-1-guid", "app-2-guid"}))
+		Expect(drainClient.unbound).To(BeEmpty())
+		Expect(drainClient.creates).To(BeEmpty())
+		Expect(drainClient.updates).To(BeEmpty())
+	})
+
+	It("does not bind other space drains", func() {
+		envFetcher.envs["app-2-guid"] = map[string]string{"DRAIN_SCOPE": "space"}
+
+		Expect(r.reconcile()).To(Succeed())
+
+		Expect(drainClient.bound).To(Equal([]string{"app-1-guid"}))
+	})
+
+	It("creates the drain if it does not exist", func() {
+		drainLister.drains = nil
+		drainLister.afterCreate = []drain.Drain{
+			{Name: "space-drain", Guid: "drain-guid"},
+		}
+
+		Expect(r.reconcile()).To(Succeed())
+
+		Expect(drainClient.creates).To(Equal([]createRequest{
+			{"space-drain", "syslog://drain.example.com", "space-guid", "all"},
+		}))
+		Expect(drainClient.bound).To(Equal([]string{"app-1-guid", "app-2-guid"}))
+	})
+
+	It("returns an error if the created drain is not found", func() {
+		drainLister.drains = nil
+
+		Expect(r.reconcile()).To(MatchError("created drain space-drain was not found"))
+		Expect(drainClient.bound).To(BeEmpty())
+	})
+
+	It("updates the drain if its URL drifted", func() {
+		drainLister.drains[0].DrainURL = "syslog://old.example.com?drain-type=logs"
+
+		Expect(r.reconcile()).To(Succeed())
+
+		Expect(drainClient.updates).To(Equal([]updateRequest{
+			{"drain-guid", "syslog://drain.example.com", "all"},
+		}))
+	})
+
+	It("does not update the drain if its URL matches", func() {
+		Expect(r.reconcile()).To(Succeed())
+
+		Expect(drainClient.updates).To(BeEmpty())
+	})
+
+	It("returns an error if updating the drain fails", func() {
+		drainLister.drains[0].DrainURL = "syslog://old.example.com?drain-type=all"
+		drainClient.updateErr = errors.New("some-error")
+
+		Expect(r.reconcile()).To(MatchError("some-error"))
+		Expect(drainClient.bound).To(BeEmpty())
+	})
+
+	It("unbinds apps that no longer match the selector", func() {
+		drainLister.drains[0].AppGuids = []string{"app-1-guid", "app-2-guid"}
+		appLister.notMatching = []string{"app-2-guid"}
+
+		Expect(r.reconcile()).To(Succeed())
+
+		Expect(drainClient.unbound).To(Equal([]string{"app-2-guid"}))
+	})
+
+	It("unbinds apps that should no longer be drained", func() {
+		drainLister.drains[0].AppGuids = []string{"app-1-guid", "removed-app-guid"}
+		envFetcher.envs["app-2-guid"] = map[string]string{"DRAIN_SCOPE": "space"}
+
+		Expect(r.reconcile()).To(Succeed())
+
+		Expect(drainClient.bound).To(BeEmpty())
+		Expect(drainClient.unbound).To(Equal([]string{"removed-app-guid"}))
+	})
+
+	It("does not unbind apps past the first page of a v2 app listing", func() {
+		curler := newStubCurler()
+		var guids []string
+		for page := 1; page <= 2; page++ {
+			var resources []string
+			for i := 0; i < 50; i++ {
+				guid := fmt.Sprintf("app-%d-%d-guid", page, i)
+				guids = append(guids, guid)
+				resources = append(resources, fmt.Sprintf(
+					`{"metadata": {"guid": %q}, "entity": {"name": "app-%d-%d"}}`,
+					guid, page, i,
+				))
+			}
+
+			path := "/v2/apps?q=space_guid:space-guid"
+			nextURL := `"/v2/apps?page=2&q=space_guid:space-guid"`
+			if page == 2 {
+				path = "/v2/apps?page=2&q=space_guid:space-guid"
+				nextURL = "null"
+			}
+			curler.resps[path] = fmt.Sprintf(
+				`{"next_url": %s, "resources": [%s]}`,
+				nextURL,
+				strings.Join(resources, ","),
+			)
+		}
+		r.appLister = cloudcontroller.NewAppListerClient(curler, cloudcontroller.V2)
+		r.cfg.DrainSelector = ""
+		drainLister.drains[0].AppGuids = guids
+
+		Expect(r.reconcile()).To(Succeed())
+
+		Expect(curler.URLs).To(HaveLen(2))
+		Expect(drainClient.bound).To(BeEmpty())
+		Expect(drainClient.unbound).To(BeEmpty())
+		Expect(st.appsBound).To(Equal(100))
+	})
+
+	It("leaves apps whose env variables could not be read as they are", func() {
+		drainLister.drains[0].AppGuids = []string{"app-1-guid"}
+		envFetcher.errs["app-1-guid"] = errors.New("some-error")
+		envFetcher.errs["app-2-guid"] = errors.New("some-error")
+
+		Expect(r.reconcile()).To(Succeed())
+
+		Expect(drainClient.bound).To(BeEmpty())
+		Expect(drainClient.unbound).To(BeEmpty())
+		Expect(logs.String()).To(ContainSubstring("failed to read env variables for app-1-guid: some-error"))
+	})
+
+	Describe("exclusion", func() {
+		It("does not bind apps excluded by annotation", func() {
+			appLister.apps[0].Annotations = map[string]string{
+				drain.ExcludeAnnotation: "other-drain, space-drain",
+			}
+
+			Expect(r.reconcile()).To(Succeed())
+
+			Expect(drainClient.bound).To(Equal([]string{"app-2-guid"}))
+		})
+
+		It("does not bind apps excluded by env variable", func() {
+			envFetcher.envs["app-2-guid"] = map[string]string{"DRAIN_EXCLUDE": "*"}
+
+			Expect(r.reconcile()).To(Succeed())
+
+			Expect(drainClient.bound).To(Equal([]string{"app-1-guid"}))
+		})
+
+		It("binds apps that exclude other space drains", func() {
+			appLister.apps[0].Annotations = map[string]string{
+				drain.ExcludeAnnotation: "other-drain",
+			}
+			envFetcher.envs["app-2-guid"] = map[string]string{"DRAIN_EXCLUDE": "other-drain"}
+
+			Expect(r.reconcile()).To(Succeed())
+
+			Expect(drainClient.bound).To(Equal([]string{"app-1-guid", "app-2-guid"}))
+		})
+
+		It("unbinds apps that opted out after they were bound", func() {
+			drainLister.drains[0].AppGuids = []string{"app-1-guid", "app-2-guid"}
+			appLister.apps[0].Annotations = map[string]string{
+				drain.ExcludeAnnotation: "space-drain",
+			}
+			envFetcher.envs["app-2-guid"] = map[string]string{"DRAIN_EXCLUDE": "space-drain"}
+
+			Expect(r.reconcile()).To(Succeed())
+
+			Expect(drainClient.unbound).To(Equal([]string{"app-1-guid", "app-2-guid"}))
+			Expect(st.appsBound).To(Equal(0))
+		})
+
+		It("unbinds an app that opted out when its event is reconciled", func() {
+			drainLister.drains[0].AppGuids = []string{"app-1-guid", "app-2-guid"}
+			appLister.apps[1].Annotations = map[string]string{
+				drain.ExcludeAnnotation: "*",
+			}
+
+			Expect(r.reconcileApps([]string{"app-2-guid"})).To(Succeed())
+
+			Expect(drainClient.unbound).To(Equal([]string{"app-2-guid"}))
+		})
+	})
+
+	Describe("reconcileApps", func() {
+		It("only binds the given apps", func() {
+			Expect(r.reconcileApps([]string{"app-2-guid"})).To(Succeed())
+
+			Expect(appLister.guidRequests).To(Equal([][]string{{"app-2-guid"}, {"app-2-guid"}}))
+			Expect(appLister.guidSelectors).To(Equal([]string{"team=payments", ""}))
+			Expect(drainClient.bound).To(Equal([]string{"app-2-guid"}))
+		})
+
+		It("lists the given apps once without a selector", func() {
+			r.cfg.DrainSelector = ""
+
+			Expect(r.reconcileApps([]string{"app-2-guid"})).To(Succeed())
+
+			Expect(appLister.guidSelectors).To(Equal([]string{""}))
+			Expect(drainClient.bound).To(Equal([]string{"app-2-guid"}))
+		})
+
+		It("does not unbind apps it did not see", func() {
+			drainLister.drains[0].AppGuids = []string{"app-1-guid", "removed-app-guid"}
+
+			Expect(r.reconcileApps([]string{"app-2-guid"})).To(Succeed())
+
+			Expect(drainClient.bound).To(Equal([]string{"app-2-guid"}))
+			Expect(drainClient.unbound).To(BeEmpty())
+		})
+
+		It("unbinds given apps that no longer match the selector", func() {
+			drainLister.drains[0].AppGuids = []string{"app-1-guid", "app-2-guid"}
+			appLister.notMatching = []string{"app-2-guid"}
+
+			Expect(r.reconcileApps([]string{"app-2-guid"})).To(Succeed())
+
+			Expect(drainClient.bound).To(BeEmpty())
+			Expect(drainClient.unbound).To(Equal([]string{"app-2-guid"}))
+		})
+
+		It("does not unbind given apps that were deleted", func() {
+			drainLister.drains[0].AppGuids = []string{"app-1-guid", "deleted-app-guid"}
+
+			Expect(r.reconcileApps([]string{"deleted-app-guid"})).To(Succeed())
+
+			Expect(drainClient.unbound).To(BeEmpty())
+		})
+
+		It("unbinds given apps that should no longer be drained", func() {
+			drainLister.drains[0].AppGuids = []string{"app-1-guid", "app-2-guid"}
+			envFetcher.envs["app-2-guid"] = map[string]string{"DRAIN_SCOPE": "space"}
+
+			Expect(r.reconcileApps([]string{"app-2-guid"})).To(Succeed())
+
+			Expect(drainClient.unbound).To(Equal([]string{"app-2-guid"}))
+		})
+	})
+
+	Describe("summary", func() {
+		It("logs what a cycle added, removed and updated", func() {
+			drainLister.drains[0].DrainURL = "syslog://old.example.com?drain-type=all"
+			drainLister.drains[0].AppGuids = []string{"app-1-guid", "removed-app-guid"}
+
+			Expect(r.reconcile()).To(Succeed())
+
+			Expect(logs.String()).To(ContainSubstring("reconciled drain: added 1, removed 1, updated 1, failed 0"))
+		})
+
+		It("counts failed binds and records them in the status", func() {
+			drainClient.bindErrs["app-2-guid"] = errors.New("some-error")
+
+			Expect(r.reconcile()).To(Succeed())
+
+			Expect(logs.String()).To(ContainSubstring("reconciled drain: added 1, removed 0, updated 0, failed 1"))
+			Expect(st.failedApps).To(Equal(map[string]string{"app-2": "some-error"}))
+			Expect(st.appsBound).To(Equal(1))
+		})
+
+		It("records the apps bound after the cycle", func() {
+			drainLister.drains[0].AppGuids = []string{"app-1-guid", "removed-app-guid"}
+
+			Expect(r.reconcile()).To(Succeed())
+
+			Expect(st.appsBound).To(Equal(2))
+			Expect(st.consecutiveFailures).To(Equal(0))
+		})
+
+		It("logs and records a failed cycle", func() {
+			drainLister.err = errors.New("some-error")
+
+			Expect(r.reconcile()).To(MatchError("some-error"))
+
+			Expect(logs.String()).To(ContainSubstring("failed to reconcile drain: some-error"))
+			Expect(st.consecutiveFailures).To(Equal(1))
+			Expect(st.unhealthy()).To(Equal("1 consecutive reconciles failed: some-error"))
+		})
+	})
+})
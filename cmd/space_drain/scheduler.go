@@ -0,0 +1,142 @@
+package main
+
+import (
+	"log"
+	"math/rand"
+	"time"
+
+	"code.cloudfoundry.org/cf-drain-cli/internal/cloudcontroller"
+)
+
+const eventPollInterval = 5 * time.Second
+
+type reconcileRunner interface {
+	reconcile() error
+	reconcileApps(appGuids []string) error
+}
+
+type eventCursor interface {
+	Next() ([]cloudcontroller.AuditEvent, error)
+}
+
+// scheduler decides when the reconciler runs. It reconciles the apps named
+// by new audit events as they arrive and does a full reconcile every resync
+// interval as a safety net. Without a cursor only full reconciles are done.
+// Failures are retried with exponential backoff.
+type scheduler struct {
+	rec            reconcileRunner
+	cursor         eventCursor
+	pollInterval   time.Duration
+	resyncInterval time.Duration
+	jitter         time.Duration
+	backoff        backoff
+	log            *log.Logger
+
+	// after returns a channel that receives once the duration has passed.
+	// It is time.After outside of tests.
+	after func(time.Duration) <-chan time.Time
+	// stop ends run when it is closed.
+	stop <-chan struct{}
+}
+
+func (s *scheduler) run() {
+	resyncBackoff := s.backoff
+	pollBackoff := s.backoff
+
+	// The first reconcile is delayed by the jitter alone so that space
+	// drains that start together spread out from the beginning.
+	resync := s.after(s.jittered(0))
+
+	var poll <-chan time.Time
+	if s.cursor != nil {
+		poll = s.after(s.pollInterval)
+	}
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-poll:
+			poll = s.after(s.poll(&pollBackoff))
+		case <-resync:
+			if err := s.rec.reconcile(); err != nil {
+				wait := resyncBackoff.next()
+				s.log.Printf("retrying reconcile in %s", wait)
+				resync = s.after(wait)
+				continue
+			}
+
+			resyncBackoff.reset()
+			resync = s.after(s.jittered(s.resyncInterval))
+		}
+	}
+}
+
+// poll reconciles the apps with new events and returns how long to wait
+// before polling again.
+func (s *scheduler) poll(b *backoff) time.Duration {
+	events, err := s.cursor.Next()
+	if err != nil {
+		s.log.Printf("failed to fetch app events: %s", err)
+		return b.next()
+	}
+
+	if len(events) != 0 {
+		// The cursor has moved past these events. If the reconcile fails
+		// they are picked up by the next full reconcile.
+		if err := s.rec.reconcileApps(eventApps(events)); err != nil {
+			return b.next()
+		}
+	}
+
+	b.reset()
+	return s.pollInterval
+}
+
+func (s *scheduler) jittered(d time.Duration) time.Duration {
+	if s.jitter <= 0 {
+		return d
+	}
+
+	return d + time.Duration(rand.Int63n(int64(s.jitter)))
+}
+
+// eventApps returns the guids of the apps the events are about.
+func eventApps(events []cloudcontroller.AuditEvent) []string {
+	var guids []string
+	for _, e := range events {
+		if !containsApp(e.AppGuid, guids) {
+			guids = append(guids, e.AppGuid)
+		}
+	}
+
+	return guids
+}
+
+// backoff doubles the wait after each consecutive failure, from initial up
+// to max.
+type backoff struct {
+	initial time.Duration
+	max     time.Duration
+
+	current time.Duration
+}
+
+func (b *backoff) next() time.Duration {
+	switch {
+	case b.current == 0:
+		b.current = b.initial
+	case b.current < b.max:
+		b.current *= 2
+	}
+
+	if b.current > b.max {
+		b.current = b.max
+	}
+
+	return b.current
+}
+
+func (b *backoff) reset() {
+	b.current = 0
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"errors"
+	"io/ioutil"
+	"log"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/cf-drain-cli/internal/cloudcontroller"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Scheduler", func() {
+	Describe("backoff", func() {
+		It("doubles the wait up to the max", func() {
+			b := backoff{initial: time.Second, max: 5 * time.Second}
+
+			Expect(b.next()).To(Equal(time.Second))
+			Expect(b.next()).To(Equal(2 * time.Second))
+			Expect(b.next()).To(Equal(4 * time.Second))
+			Expect(b.next()).To(Equal(5 * time.Second))
+			Expect(b.next()).To(Equal(5 * time.Second))
+		})
+
+		It("starts over after a reset", func() {
+			b := backoff{initial: time.Second, max: 5 * time.Second}
+			b.next()
+			b.next()
+
+			b.reset()
+
+			Expect(b.next()).To(Equal(time.Second))
+		})
+	})
+
+	Describe("jittered", func() {
+		It("adds a delay smaller than the jitter", func() {
+			s := &scheduler{jitter: time.Second}
+
+			for i := 0; i < 1000; i++ {
+				d := s.jittered(time.Minute)
+				Expect(d).To(BeNumerically(">=", time.Minute))
+				Expect(d).To(BeNumerically("<", time.Minute+time.Second))
+			}
+		})
+
+		It("adds nothing without a jitter", func() {
+			s := &scheduler{}
+
+			Expect(s.jittered(time.Minute)).To(Equal(time.Minute))
+		})
+	})
+
+	Describe("run", func() {
+		var (
+			rec    *stubReconciler
+			cursor *stubCursor
+			clock  *fakeClock
+			stop   chan struct{}
+			s      *scheduler
+		)
+
+		BeforeEach(func() {
+			rec = &stubReconciler{}
+			cursor = &stubCursor{}
+			clock = newFakeClock()
+			stop = make(chan struct{})
+			s = &scheduler{
+				rec:            rec,
+				pollInterval:   5 * time.Second,
+				resyncInterval: 10 * time.Minute,
+				backoff:        backoff{initial: time.Second, max: 4 * time.Second},
+				log:            log.New(ioutil.Discard, "", 0),
+				after:          clock.after,
+				stop:           stop,
+			}
+		})
+
+		AfterEach(func() {
+			close(stop)
+		})
+
+		It("reconciles right away and then every resync interval", func() {
+			go s.run()
+
+			clock.expect(0).fire()
+			clock.expect(10 * time.Minute).fire()
+			clock.expect(10 * time.Minute)
+
+			Expect(rec.fullCount()).To(Equal(2))
+		})
+
+		It("retries failed reconciles with backoff and resets it on success", func() {
+			rec.errs = []error{
+				errors.New("some-error"),
+				errors.New("some-error"),
+				errors.New("some-error"),
+				errors.New("some-error"),
+				nil,
+				errors.New("some-error"),
+			}
+			go s.run()
+
+			clock.expect(0).fire()
+			clock.expect(time.Second).fire()
+			clock.expect(2 * time.Second).fire()
+			clock.expect(4 * time.Second).fire()
+			clock.expect(4 * time.Second).fire()
+			clock.expect(10 * time.Minute).fire()
+			clock.expect(time.Second)
+		})
+
+		It("adds the jitter to the resync interval", func() {
+			s.jitter = time.Second
+			go s.run()
+
+			t := clock.next()
+			Expect(t.d).To(BeNumerically("<", time.Second))
+			t.fire()
+
+			t = clock.next()
+			Expect(t.d).To(BeNumerically(">=", 10*time.Minute))
+			Expect(t.d).To(BeNumerically("<", 10*time.Minute+time.Second))
+		})
+
+		Context("with a cursor", func() {
+			var (
+				resync *fakeTimer
+				poll   *fakeTimer
+			)
+
+			BeforeEach(func() {
+				s.cursor = cursor
+			})
+
+			JustBeforeEach(func() {
+				go s.run()
+
+				resync = clock.expect(0)
+				poll = clock.expect(5 * time.Second)
+			})
+
+			It("reconciles the apps of new events", func() {
+				cursor.results = []cursorResult{
+					{events: []cloudcontroller.AuditEvent{
+						{AppGuid: "app-1-guid"},
+						{AppGuid: "app-2-guid"},
+						{AppGuid: "app-1-guid"},
+					}},
+				}
+
+				poll.fire()
+				clock.expect(5 * time.Second)
+
+				Expect(rec.partialCalls()).To(Equal([][]string{{"app-1-guid", "app-2-guid"}}))
+				Expect(rec.fullCount()).To(Equal(0))
+			})
+
+			It("does not reconcile without new events", func() {
+				poll.fire()
+				clock.expect(5 * time.Second)
+
+				Expect(rec.partialCalls()).To(BeEmpty())
+			})
+
+			It("backs off while fetching events fails", func() {
+				cursor.results = []cursorResult{
+					{err: errors.New("some-error")},
+					{err: errors.New("some-error")},
+					{},
+				}
+
+				poll.fire()
+				clock.expect(time.Second).fire()
+				clock.expect(2 * time.Second).fire()
+				clock.expect(5 * time.Second)
+			})
+
+			It("backs off if reconciling the apps fails", func() {
+				cursor.results = []cursorResult{
+					{events: []cloudcontroller.AuditEvent{{AppGuid: "app-1-guid"}}},
+				}
+				rec.errs = []error{errors.New("some-error")}
+
+				poll.fire()
+				clock.expect(time.Second)
+			})
+
+			It("still does full reconciles", func() {
+				resync.fire()
+				clock.expect(10 * time.Minute)
+
+				Expect(rec.fullCount()).To(Equal(1))
+			})
+		})
+	})
+})
+
+type stubReconciler struct {
+	mu      sync.Mutex
+	errs    []error
+	full    int
+	partial [][]string
+}
+
+func (r *stubReconciler) reconcile() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.full++
+	return r.nextErr()
+}
+
+func (r *stubReconciler) reconcileApps(appGuids []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.partial = append(r.partial, appGuids)
+	return r.nextErr()
+}
+
+func (r *stubReconciler) nextErr() error {
+	if len(r.errs) == 0 {
+		return nil
+	}
+
+	err := r.errs[0]
+	r.errs = r.errs[1:]
+	return err
+}
+
+func (r *stubReconciler) fullCount() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.full
+}
+
+func (r *stubReconciler) partialCalls() [][]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.partial
+}
+
+type cursorResult struct {
+	events []cloudcontroller.AuditEvent
+	err    error
+}
+
+type stubCursor struct {
+	results []cursorResult
+}
+
+func (c *stubCursor) Next() ([]cloudcontroller.AuditEvent, error) {
+	if len(c.results) == 0 {
+		return nil, nil
+	}
+
+	r := c.results[0]
+	c.results = c.results[1:]
+	return r.events, r.err
+}
+
+// fakeClock hands the timers the scheduler starts to the test, which fires
+// them.
+type fakeClock struct {
+	timers chan *fakeTimer
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{
+		timers: make(chan *fakeTimer, 10),
+	}
+}
+
+func (c *fakeClock) after(d time.Duration) <-chan time.Time {
+	t := &fakeTimer{d: d, c: make(chan time.Time, 1)}
+	c.timers <- t
+	return t.c
+}
+
+func (c *fakeClock) next() *fakeTimer {
+	var t *fakeTimer
+	EventuallyWithOffset(1, c.timers).Should(Receive(&t))
+	return t
+}
+
+// expect waits for the next timer and checks its duration.
+func (c *fakeClock) expect(d time.Duration) *fakeTimer {
+	var t *fakeTimer
+	EventuallyWithOffset(1, c.timers).Should(Receive(&t))
+	ExpectWithOffset(1, t.d).To(Equal(d))
+	return t
+}
+
+type fakeTimer struct {
+	d time.Duration
+	c chan time.Time
+}
+
+func (t *fakeTimer) fire() {
+	t.c <- time.Time{}
+}
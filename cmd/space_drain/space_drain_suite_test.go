@@ -0,0 +1,172 @@
+package main
+
+import (
+	"sync"
+	"testing"
+
+	"code.cloudfoundry.org/cf-drain-cli/internal/cloudcontroller"
+	"code.cloudfoundry.org/cf-drain-cli/internal/drain"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestSpaceDrain(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Space Drain Suite")
+}
+
+type stubDrainLister struct {
+	drains []drain.Drain
+	err    error
+
+	// afterCreate is returned once the drain has been created.
+	afterCreate []drain.Drain
+	created     bool
+}
+
+func (s *stubDrainLister) Drains(spaceGuid string) ([]drain.Drain, error) {
+	if s.created {
+		return s.afterCreate, s.err
+	}
+
+	return s.drains, s.err
+}
+
+type createRequest struct {
+	name, url, spaceGuid, drainType string
+}
+
+type updateRequest struct {
+	guid, url, drainType string
+}
+
+// stubDrainClient records the drains that are created and updated and the
+// apps that are bound and unbound.
+type stubDrainClient struct {
+	lister *stubDrainLister
+
+	mu        sync.Mutex
+	creates   []createRequest
+	updates   []updateRequest
+	bound     []string
+	unbound   []string
+	createErr error
+	updateErr error
+	bindErrs  map[string]error
+}
+
+func newStubDrainClient(l *stubDrainLister) *stubDrainClient {
+	return &stubDrainClient{
+		lister:   l,
+		bindErrs: make(map[string]error),
+	}
+}
+
+func (s *stubDrainClient) CreateDrain(name, url, spaceGuid, drainType string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.creates = append(s.creates, createRequest{name, url, spaceGuid, drainType})
+	if s.createErr == nil {
+		s.lister.created = true
+	}
+	return s.createErr
+}
+
+func (s *stubDrainClient) UpdateDrain(serviceInstanceGuid, url, drainType string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.updates = append(s.updates, updateRequest{serviceInstanceGuid, url, drainType})
+	return s.updateErr
+}
+
+func (s *stubDrainClient) BindDrain(appGuid, serviceInstanceGuid string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.bound = append(s.bound, appGuid)
+	return s.bindErrs[appGuid]
+}
+
+func (s *stubDrainClient) UnbindDrain(appGuid, serviceInstanceGuid string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.unbound = append(s.unbound, appGuid)
+	return s.bindErrs[appGuid]
+}
+
+// stubAppLister lists its apps. The guids in notMatching are left out when
+// a selector is given.
+type stubAppLister struct {
+	apps        []cloudcontroller.App
+	notMatching []string
+	err         error
+
+	selectors     []string
+	guidSelectors []string
+	guidRequests  [][]string
+}
+
+func (s *stubAppLister) ListAppsWithSelector(spaceGuid, selector string) ([]cloudcontroller.App, error) {
+	s.selectors = append(s.selectors, selector)
+	return s.list(selector, nil), s.err
+}
+
+func (s *stubAppLister) ListAppsByGuid(spaceGuid, selector string, guids []string) ([]cloudcontroller.App, error) {
+	s.guidSelectors = append(s.guidSelectors, selector)
+	s.guidRequests = append(s.guidRequests, guids)
+	return s.list(selector, guids), s.err
+}
+
+func (s *stubAppLister) list(selector string, guids []string) []cloudcontroller.App {
+	var apps []cloudcontroller.App
+	for _, app := range s.apps {
+		if guids != nil && !containsApp(app.Guid, guids) {
+			continue
+		}
+		if selector != "" && containsApp(app.Guid, s.notMatching) {
+			continue
+		}
+		apps = append(apps, app)
+	}
+	return apps
+}
+
+type stubEnvFetcher struct {
+	envs map[string]map[string]string
+	errs map[string]error
+}
+
+func newStubEnvFetcher() *stubEnvFetcher {
+	return &stubEnvFetcher{
+		envs: make(map[string]map[string]string),
+		errs: make(map[string]error),
+	}
+}
+
+func (s *stubEnvFetcher) EnvVars(appGuid string) (map[string]string, error) {
+	if err, ok := s.errs[appGuid]; ok {
+		return nil, err
+	}
+
+	return s.envs[appGuid], nil
+}
+
+type stubCurler struct {
+	URLs  []string
+	resps map[string]string
+}
+
+func newStubCurler() *stubCurler {
+	return &stubCurler{
+		resps: make(map[string]string),
+	}
+}
+
+func (s *stubCurler) Curl(URL, method, body string) ([]byte, error) {
+	s.URLs = append(s.URLs, URL)
+	return []byte(s.resps[URL]), nil
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/cf-drain-cli/internal/cloudcontroller"
+	"code.cloudfoundry.org/cf-drain-cli/internal/drain"
+)
+
+// status tracks the outcome of reconciles and token refreshes for the
+// /health and /status endpoints.
+type status struct {
+	failureThreshold int
+	drainURL         string
+	drainType        string
+
+	mu                  sync.Mutex
+	lastSync            time.Time
+	appsBound           int
+	failedApps          map[string]string
+	consecutiveFailures int
+	lastError           string
+	tokenError          string
+}
+
+func newStatus(failureThreshold int, drainURL, drainType string) *status {
+	redacted := "<unparseable drain URL>"
+	if u, err := url.Parse(drainURL); err == nil {
+		redacted = drain.RedactURL(u, nil)
+	}
+
+	return &status{
+		failureThreshold: failureThreshold,
+		drainURL:         redacted,
+		drainType:        drainType,
+		failedApps:       make(map[string]string),
+	}
+}
+
+// recordReconcile records the result of a reconcile. A full reconcile
+// replaces the failed apps, a partial one only updates the apps it touched.
+func (s *status) recordReconcile(summary reconcileSummary, full bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err != nil {
+		s.consecutiveFailures++
+		s.lastError = err.Error()
+		return
+	}
+
+	s.consecutiveFailures = 0
+	s.lastError = ""
+	s.lastSync = time.Now()
+	s.appsBound = summary.AppsBound
+
+	if full {
+		s.failedApps = make(map[string]string)
+	}
+	for _, app := range summary.BoundApps {
+		delete(s.failedApps, app)
+	}
+	for app, msg := range summary.FailedApps {
+		s.failedApps[app] = msg
+	}
+}
+
+func (s *status) recordToken(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tokenError = ""
+	if err != nil {
+		s.tokenError = err.Error()
+	}
+}
+
+// unhealthy returns why the space drain is unhealthy, or an empty string if
+// it is healthy.
+func (s *status) unhealthy() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.tokenError != "" {
+		return "token refresh failed: " + s.tokenError
+	}
+
+	if s.consecutiveFailures >= s.failureThreshold {
+		return fmt.Sprintf("%d consecutive reconciles failed: %s", s.consecutiveFailures, s.lastError)
+	}
+
+	return ""
+}
+
+func (s *status) healthHandler(w http.ResponseWriter, r *http.Request) {
+	reason := s.unhealthy()
+
+	w.Header().Set("Content-Type", "application/json")
+	if reason != "" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"healthy": false,
+			"reason":  reason,
+		})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"healthy": true,
+	})
+}
+
+type failedApp struct {
+	Name  string `json:"name"`
+	Error string `json:"error"`
+}
+
+type statusResponse struct {
+	LastSync            *time.Time  `json:"last_sync"`
+	AppsBound           int         `json:"apps_bound"`
+	FailedApps          []failedApp `json:"failed_apps"`
+	DrainURL            string      `json:"drain_url"`
+	DrainType           string      `json:"drain_type"`
+	ConsecutiveFailures int         `json:"consecutive_failures"`
+	LastError           string      `json:"last_error,omitempty"`
+	TokenError          string      `json:"token_error,omitempty"`
+}
+
+func (s *status) statusHandler(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	resp := statusResponse{
+		AppsBound:           s.appsBound,
+		FailedApps:          []failedApp{},
+		DrainURL:            s.drainURL,
+		DrainType:           s.drainType,
+		ConsecutiveFailures: s.consecutiveFailures,
+		LastError:           s.lastError,
+		TokenError:          s.tokenError,
+	}
+	if !s.lastSync.IsZero() {
+		lastSync := s.lastSync
+		resp.LastSync = &lastSync
+	}
+	for name, msg := range s.failedApps {
+		resp.FailedApps = append(resp.FailedApps, failedApp{Name: name, Error: msg})
+	}
+	s.mu.Unlock()
+
+	sort.Slice(resp.FailedApps, func(i, j int) bool {
+		return resp.FailedApps[i].Name < resp.FailedApps[j].Name
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// requireToken only passes on requests that send the token as a bearer
+// token.
+func requireToken(token string, h http.Handler) http.Handler {
+	want := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got := []byte(r.Header.Get("Authorization"))
+		if subtle.ConstantTimeCompare(got, want) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
+
+// tokenHealth records whether fetching tokens succeeds.
+type tokenHealth struct {
+	cloudcontroller.TokenFetcher
+	status  *status
+	metrics *spaceDrainMetrics
+}
+
+func (t tokenHealth) Token() (string, string, error) {
+	accToken, refToken, err := t.TokenFetcher.Token()
+	t.status.recordToken(err)
+	t.metrics.tokenRefreshes.Inc(result(err))
+	return accToken, refToken, err
+}
@@ -15,4 +15,5 @@ require (
 	github.com/onsi/ginkgo v1.12.1
 	github.com/onsi/gomega v1.10.0
 	google.golang.org/grpc v1.29.1 // indirect
+	gopkg.in/yaml.v2 v2.2.4
 )
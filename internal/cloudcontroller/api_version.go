@@ -0,0 +1,45 @@
+package cloudcontroller
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// APIVersion is the Cloud Controller API used for service instances and
+// their bindings.
+type APIVersion int
+
+const (
+	V2 APIVersion = iota
+	V3
+)
+
+func (v APIVersion) String() string {
+	if v == V3 {
+		return "v3"
+	}
+	return "v2"
+}
+
+// ProbeAPIVersion reads the v3 API root and returns V3 if it advertises
+// service credential bindings. Older Cloud Controllers that cannot manage
+// user provided services through v3 fall back to V2.
+func ProbeAPIVersion(c Curler) APIVersion {
+	resp, err := c.Curl("/v3", http.MethodGet, "")
+	if err != nil {
+		return V2
+	}
+
+	var root struct {
+		Links map[string]json.RawMessage `json:"links"`
+	}
+	if err := json.Unmarshal(resp, &root); err != nil {
+		return V2
+	}
+
+	if _, ok := root.Links["service_credential_bindings"]; ok {
+		return V3
+	}
+
+	return V2
+}
@@ -0,0 +1,54 @@
+package cloudcontroller_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/cf-drain-cli/internal/cloudcontroller"
+)
+
+var _ = Describe("ProbeAPIVersion", func() {
+	var curler *stubCurler
+
+	BeforeEach(func() {
+		curler = newStubCurler()
+	})
+
+	It("returns V3 when the v3 root has service credential bindings", func() {
+		curler.resps["/v3"] = `{
+			"links": {
+				"self": {"href": "https://api.example.com/v3"},
+				"service_credential_bindings": {"href": "https://api.example.com/v3/service_credential_bindings"}
+			}
+		}`
+
+		Expect(cloudcontroller.ProbeAPIVersion(curler)).To(Equal(cloudcontroller.V3))
+		Expect(curler.URLs).To(ConsistOf("/v3"))
+		Expect(curler.methods).To(ConsistOf("GET"))
+	})
+
+	It("returns V2 when the v3 root does not have service credential bindings", func() {
+		curler.resps["/v3"] = `{
+			"links": {
+				"self": {"href": "https://api.example.com/v3"},
+				"apps": {"href": "https://api.example.com/v3/apps"}
+			}
+		}`
+
+		Expect(cloudcontroller.ProbeAPIVersion(curler)).To(Equal(cloudcontroller.V2))
+	})
+
+	It("returns V2 when the v3 root can not be read", func() {
+		curler.errs["/v3"] = errors.New("some-error")
+
+		Expect(cloudcontroller.ProbeAPIVersion(curler)).To(Equal(cloudcontroller.V2))
+	})
+
+	It("returns V2 when the v3 root is not JSON", func() {
+		curler.resps["/v3"] = "404 Not Found"
+
+		Expect(cloudcontroller.ProbeAPIVersion(curler)).To(Equal(cloudcontroller.V2))
+	})
+})
@@ -2,12 +2,19 @@ package cloudcontroller
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/url"
+	"strings"
 )
 
 type App struct {
 	Name string
 	Guid string
+
+	// Annotations are the app's metadata annotations. They are only
+	// available with the v3 API.
+	Annotations map[string]string
 }
 
 type Curler interface {
@@ -16,43 +23,131 @@ type Curler interface {
 
 type AppListerClient struct {
 	c Curler
+	v APIVersion
 }
 
-func NewAppListerClient(c Curler) *AppListerClient {
+func NewAppListerClient(c Curler, v APIVersion) *AppListerClient {
 	return &AppListerClient{
 		c: c,
+		v: v,
 	}
 }
 
+// ListApps returns every app in the space. All pages of the listing are
+// followed.
 func (c *AppListerClient) ListApps(spaceGuid string) ([]App, error) {
-	resp, err := c.c.Curl(
-		fmt.Sprintf("/v2/apps?q=space_guid:%s", spaceGuid),
-		"GET",
-		"",
-	)
-
-	if err != nil {
-		return nil, err
+	if c.v == V3 {
+		return c.listV3Apps(spaceGuid, "", nil)
 	}
 
-	var apps struct {
-		Resources []struct {
-			Metadata struct {
-				Guid string
-			}
-			Entity struct {
-				Name string
+	var a []App
+	path := fmt.Sprintf("/v2/apps?q=space_guid:%s", spaceGuid)
+	for path != "" {
+		resp, err := c.c.Curl(path, "GET", "")
+		if err != nil {
+			return nil, err
+		}
+
+		var apps struct {
+			NextURL   string `json:"next_url"`
+			Resources []struct {
+				Metadata struct {
+					Guid string
+				}
+				Entity struct {
+					Name string
+				}
 			}
 		}
+		err = json.Unmarshal(resp, &apps)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, r := range apps.Resources {
+			a = append(a, App{Name: r.Entity.Name, Guid: r.Metadata.Guid})
+		}
+
+		path = apps.NextURL
 	}
-	err = json.Unmarshal(resp, &apps)
-	if err != nil {
-		return nil, err
+
+	return a, nil
+}
+
+// ListAppsWithSelector returns the apps in the space whose labels match the
+// given label selector. Labels are only available with the v3 API. An empty
+// selector matches every app.
+func (c *AppListerClient) ListAppsWithSelector(spaceGuid, selector string) ([]App, error) {
+	if selector == "" {
+		return c.ListApps(spaceGuid)
+	}
+
+	if c.v != V3 {
+		return nil, errors.New("label selectors require the v3 cloud controller API")
+	}
+
+	return c.listV3Apps(spaceGuid, selector, nil)
+}
+
+// ListAppsByGuid returns the apps with the given guids that are in the space
+// and match the label selector. It requires the v3 API.
+func (c *AppListerClient) ListAppsByGuid(spaceGuid, selector string, guids []string) ([]App, error) {
+	if c.v != V3 {
+		return nil, errors.New("listing apps by guid requires the v3 cloud controller API")
+	}
+
+	if len(guids) == 0 {
+		return nil, nil
+	}
+
+	return c.listV3Apps(spaceGuid, selector, guids)
+}
+
+func (c *AppListerClient) listV3Apps(spaceGuid, selector string, guids []string) ([]App, error) {
+	params := url.Values{
+		"space_guids": {spaceGuid},
+	}
+	if selector != "" {
+		params.Set("label_selector", selector)
+	}
+	if len(guids) != 0 {
+		params.Set("guids", strings.Join(guids, ","))
 	}
 
 	var a []App
-	for _, r := range apps.Resources {
-		a = append(a, App{r.Entity.Name, r.Metadata.Guid})
+	path := "/v3/apps?" + params.Encode()
+	for path != "" {
+		resp, err := c.c.Curl(path, "GET", "")
+		if err != nil {
+			return nil, err
+		}
+
+		var apps struct {
+			Pagination struct {
+				Next Link `json:"next"`
+			} `json:"pagination"`
+			Resources []struct {
+				Guid     string `json:"guid"`
+				Name     string `json:"name"`
+				Metadata struct {
+					Annotations map[string]string `json:"annotations"`
+				} `json:"metadata"`
+			} `json:"resources"`
+		}
+		err = json.Unmarshal(resp, &apps)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, r := range apps.Resources {
+			a = append(a, App{
+				Name:        r.Name,
+				Guid:        r.Guid,
+				Annotations: r.Metadata.Annotations,
+			})
+		}
+
+		path = apps.Pagination.Next.Path()
 	}
 
 	return a, nil
@@ -17,7 +17,7 @@ var _ = Describe("AppListerClient", func() {
 
 	BeforeEach(func() {
 		curler = newStubCurler()
-		c = cloudcontroller.NewAppListerClient(curler)
+		c = cloudcontroller.NewAppListerClient(curler, cloudcontroller.V2)
 	})
 
 	It("requests all apps in the space", func() {
@@ -50,6 +50,36 @@ var _ = Describe("AppListerClient", func() {
 			}))
 	})
 
+	It("follows the next_url of every page", func() {
+		curler.resps["/v2/apps?q=space_guid:some-space"] = `
+		{
+			"next_url": "/v2/apps?order-direction=asc&page=2&q=space_guid:some-space",
+			"resources": [
+				{"metadata": {"guid": "a"}, "entity": {"name": "app-1"}}
+			]
+		}
+		`
+		curler.resps["/v2/apps?order-direction=asc&page=2&q=space_guid:some-space"] = `
+		{
+			"next_url": null,
+			"resources": [
+				{"metadata": {"guid": "b"}, "entity": {"name": "app-2"}}
+			]
+		}
+		`
+
+		apps, err := c.ListApps("some-space")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(curler.URLs).To(Equal([]string{
+			"/v2/apps?q=space_guid:some-space",
+			"/v2/apps?order-direction=asc&page=2&q=space_guid:some-space",
+		}))
+		Expect(apps).To(Equal([]cloudcontroller.App{
+			{Name: "app-1", Guid: "a"},
+			{Name: "app-2", Guid: "b"},
+		}))
+	})
+
 	It("returns an error if the GET fails", func() {
 		curler.errs["/v2/apps?q=space_guid:some-space"] = errors.New("some-error")
 		_, err := c.ListApps("some-space")
@@ -61,6 +91,146 @@ var _ = Describe("AppListerClient", func() {
 		_, err := c.ListApps("some-space")
 		Expect(err).To(HaveOccurred())
 	})
+
+	It("returns an error for label selectors", func() {
+		_, err := c.ListAppsWithSelector("some-space", "team=payments")
+		Expect(err).To(MatchError("label selectors require the v3 cloud controller API"))
+		Expect(curler.URLs).To(BeEmpty())
+	})
+
+	It("returns an error when listing apps by guid", func() {
+		_, err := c.ListAppsByGuid("some-space", "", []string{"a"})
+		Expect(err).To(MatchError("listing apps by guid requires the v3 cloud controller API"))
+	})
+
+	It("lists every app for an empty selector", func() {
+		curler.resps["/v2/apps?q=space_guid:some-space"] = `{"resources": []}`
+
+		_, err := c.ListAppsWithSelector("some-space", "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(curler.URLs).To(ConsistOf("/v2/apps?q=space_guid:some-space"))
+	})
+
+	Context("with the v3 API", func() {
+		BeforeEach(func() {
+			c = cloudcontroller.NewAppListerClient(curler, cloudcontroller.V3)
+		})
+
+		It("requests all apps in the space and follows pagination", func() {
+			curler.resps["/v3/apps?space_guids=some-space"] = `
+			{
+				"pagination": {
+					"next": {"href": "https://api.example.com/v3/apps?page=2&space_guids=some-space"}
+				},
+				"resources": [
+					{"guid": "a", "name": "app-1"}
+				]
+			}
+			`
+			curler.resps["/v3/apps?page=2&space_guids=some-space"] = `
+			{
+				"pagination": {"next": null},
+				"resources": [
+					{"guid": "b", "name": "app-2"}
+				]
+			}
+			`
+
+			apps, err := c.ListApps("some-space")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(curler.methods).To(ConsistOf("GET", "GET"))
+			Expect(curler.URLs).To(Equal([]string{
+				"/v3/apps?space_guids=some-space",
+				"/v3/apps?page=2&space_guids=some-space",
+			}))
+			Expect(apps).To(Equal([]cloudcontroller.App{
+				{Name: "app-1", Guid: "a"},
+				{Name: "app-2", Guid: "b"},
+			}))
+		})
+
+		It("includes the app annotations", func() {
+			curler.resps["/v3/apps?space_guids=some-space"] = `
+			{
+				"pagination": {"next": null},
+				"resources": [
+					{
+						"guid": "a",
+						"name": "app-1",
+						"metadata": {
+							"labels": {"team": "payments"},
+							"annotations": {"drains.cloudfoundry.org/exclude": "space-drain"}
+						}
+					}
+				]
+			}
+			`
+
+			apps, err := c.ListApps("some-space")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(apps).To(Equal([]cloudcontroller.App{
+				{
+					Name:        "app-1",
+					Guid:        "a",
+					Annotations: map[string]string{"drains.cloudfoundry.org/exclude": "space-drain"},
+				},
+			}))
+		})
+
+		It("lists apps by guid", func() {
+			curler.resps["/v3/apps?guids=a%2Cb&label_selector=env%3Dprod&space_guids=some-space"] = `
+			{
+				"pagination": {"next": null},
+				"resources": [
+					{"guid": "a", "name": "app-1"}
+				]
+			}
+			`
+
+			apps, err := c.ListAppsByGuid("some-space", "env=prod", []string{"a", "b"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(apps).To(Equal([]cloudcontroller.App{
+				{Name: "app-1", Guid: "a"},
+			}))
+		})
+
+		It("does not list apps for no guids", func() {
+			apps, err := c.ListAppsByGuid("some-space", "", nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(apps).To(BeEmpty())
+			Expect(curler.URLs).To(BeEmpty())
+		})
+
+		It("filters apps by label selector", func() {
+			curler.resps["/v3/apps?label_selector=team%3Dpayments&space_guids=some-space"] = `
+			{
+				"pagination": {"next": null},
+				"resources": [
+					{"guid": "a", "name": "app-1"}
+				]
+			}
+			`
+
+			apps, err := c.ListAppsWithSelector("some-space", "team=payments")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(curler.URLs).To(ConsistOf("/v3/apps?label_selector=team%3Dpayments&space_guids=some-space"))
+			Expect(apps).To(Equal([]cloudcontroller.App{
+				{Name: "app-1", Guid: "a"},
+			}))
+		})
+
+		It("returns an error if the GET fails", func() {
+			curler.errs["/v3/apps?space_guids=some-space"] = errors.New("some-error")
+			_, err := c.ListApps("some-space")
+			Expect(err).To(MatchError("some-error"))
+		})
+
+		It("returns an error if the JSON is invalid", func() {
+			curler.resps["/v3/apps?space_guids=some-space"] = `invalid`
+			_, err := c.ListApps("some-space")
+			Expect(err).To(HaveOccurred())
+		})
+	})
 })
 
 type stubCurler struct {
@@ -0,0 +1,126 @@
+package cloudcontroller
+
+import (
+	"encoding/json"
+	"net/url"
+	"time"
+)
+
+// AuditEvent is a v3 audit event about an app.
+type AuditEvent struct {
+	Guid      string
+	Type      string
+	CreatedAt time.Time
+	AppGuid   string
+}
+
+type AuditEventClient struct {
+	c Curler
+}
+
+func NewAuditEventClient(c Curler) *AuditEventClient {
+	return &AuditEventClient{
+		c: c,
+	}
+}
+
+// AppEvents returns the app create, start and update events in the space
+// that were created at or after since, oldest first. Update events cover
+// changes to an app's labels, annotations and environment variables. Audit
+// events are only available with the v3 API.
+func (c *AuditEventClient) AppEvents(spaceGuid string, since time.Time) ([]AuditEvent, error) {
+	params := url.Values{
+		"types":            {"audit.app.create,audit.app.start,audit.app.update"},
+		"space_guids":      {spaceGuid},
+		"created_ats[gte]": {since.UTC().Format(time.RFC3339)},
+		"order_by":         {"created_at"},
+	}
+
+	var events []AuditEvent
+	path := "/v3/audit_events?" + params.Encode()
+	for path != "" {
+		resp, err := c.c.Curl(path, "GET", "")
+		if err != nil {
+			return nil, err
+		}
+
+		var eventsResp struct {
+			Pagination struct {
+				Next Link `json:"next"`
+			} `json:"pagination"`
+			Resources []struct {
+				Guid      string    `json:"guid"`
+				Type      string    `json:"type"`
+				CreatedAt time.Time `json:"created_at"`
+				Target    struct {
+					Guid string `json:"guid"`
+				} `json:"target"`
+			} `json:"resources"`
+		}
+		err = json.Unmarshal(resp, &eventsResp)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, r := range eventsResp.Resources {
+			events = append(events, AuditEvent{
+				Guid:      r.Guid,
+				Type:      r.Type,
+				CreatedAt: r.CreatedAt,
+				AppGuid:   r.Target.Guid,
+			})
+		}
+
+		path = eventsResp.Pagination.Next.Path()
+	}
+
+	return events, nil
+}
+
+// AuditEventCursor remembers the last audit event it has seen so that each
+// call to Next only returns new events.
+type AuditEventCursor struct {
+	c         *AuditEventClient
+	spaceGuid string
+
+	since time.Time
+	// seen holds the events created at since. Audit event timestamps only
+	// have second precision, so events at the cursor are fetched again and
+	// filtered out.
+	seen map[string]bool
+}
+
+// NewAuditEventCursor returns a cursor for the app events in the space that
+// are created at or after start.
+func NewAuditEventCursor(c *AuditEventClient, spaceGuid string, start time.Time) *AuditEventCursor {
+	return &AuditEventCursor{
+		c:         c,
+		spaceGuid: spaceGuid,
+		since:     start.UTC().Truncate(time.Second),
+		seen:      make(map[string]bool),
+	}
+}
+
+// Next returns the app events that were created since the previous call.
+func (c *AuditEventCursor) Next() ([]AuditEvent, error) {
+	events, err := c.c.AppEvents(c.spaceGuid, c.since)
+	if err != nil {
+		return nil, err
+	}
+
+	var unseen []AuditEvent
+	for _, e := range events {
+		if c.seen[e.Guid] {
+			continue
+		}
+		unseen = append(unseen, e)
+
+		if e.CreatedAt.After(c.since) {
+			c.since = e.CreatedAt
+			c.seen = make(map[string]bool)
+		}
+		c.seen[e.Guid] = true
+	}
+
+	return unseen, nil
+}
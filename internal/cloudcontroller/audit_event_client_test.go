@@ -0,0 +1,131 @@
+package cloudcontroller_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/cf-drain-cli/internal/cloudcontroller"
+)
+
+var _ = Describe("AuditEventClient", func() {
+	var (
+		curler *stubCurler
+		c      *cloudcontroller.AuditEventClient
+		start  time.Time
+	)
+
+	const (
+		firstPage  = "/v3/audit_events?created_ats%5Bgte%5D=2020-01-02T03%3A04%3A05Z&order_by=created_at&space_guids=some-space&types=audit.app.create%2Caudit.app.start%2Caudit.app.update"
+		secondPage = "/v3/audit_events?created_ats%5Bgte%5D=2020-01-02T03%3A04%3A05Z&order_by=created_at&page=2&space_guids=some-space&types=audit.app.create%2Caudit.app.start%2Caudit.app.update"
+		laterPage  = "/v3/audit_events?created_ats%5Bgte%5D=2020-01-02T03%3A04%3A06Z&order_by=created_at&space_guids=some-space&types=audit.app.create%2Caudit.app.start%2Caudit.app.update"
+	)
+
+	BeforeEach(func() {
+		curler = newStubCurler()
+		c = cloudcontroller.NewAuditEventClient(curler)
+		start = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	})
+
+	It("requests app events in the space since the given time", func() {
+		curler.resps[firstPage] = `
+		{
+			"pagination": {"next": {"href": "https://api.example.com` + secondPage + `"}},
+			"resources": [
+				{
+					"guid": "event-1",
+					"type": "audit.app.create",
+					"created_at": "2020-01-02T03:04:05Z",
+					"target": {"guid": "app-1", "type": "app", "name": "app-name-1"}
+				}
+			]
+		}`
+		curler.resps[secondPage] = `
+		{
+			"pagination": {"next": null},
+			"resources": [
+				{
+					"guid": "event-2",
+					"type": "audit.app.start",
+					"created_at": "2020-01-02T03:04:06Z",
+					"target": {"guid": "app-2", "type": "app", "name": "app-name-2"}
+				}
+			]
+		}`
+
+		events, err := c.AppEvents("some-space", start)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(curler.URLs).To(Equal([]string{firstPage, secondPage}))
+		Expect(events).To(Equal([]cloudcontroller.AuditEvent{
+			{
+				Guid:      "event-1",
+				Type:      "audit.app.create",
+				CreatedAt: start,
+				AppGuid:   "app-1",
+			},
+			{
+				Guid:      "event-2",
+				Type:      "audit.app.start",
+				CreatedAt: start.Add(time.Second),
+				AppGuid:   "app-2",
+			},
+		}))
+	})
+
+	It("returns an error if the GET fails", func() {
+		curler.errs[firstPage] = errors.New("some-error")
+
+		_, err := c.AppEvents("some-space", start)
+		Expect(err).To(MatchError("some-error"))
+	})
+
+	Describe("AuditEventCursor", func() {
+		It("only returns events it has not returned before", func() {
+			cursor := cloudcontroller.NewAuditEventCursor(c, "some-space", start.Add(500*time.Millisecond))
+
+			curler.resps[firstPage] = `
+			{
+				"resources": [
+					{"guid": "event-1", "created_at": "2020-01-02T03:04:05Z", "target": {"guid": "app-1"}},
+					{"guid": "event-2", "created_at": "2020-01-02T03:04:06Z", "target": {"guid": "app-2"}}
+				]
+			}`
+			events, err := cursor.Next()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(events).To(HaveLen(2))
+
+			curler.resps[laterPage] = `
+			{
+				"resources": [
+					{"guid": "event-2", "created_at": "2020-01-02T03:04:06Z", "target": {"guid": "app-2"}},
+					{"guid": "event-3", "created_at": "2020-01-02T03:04:06Z", "target": {"guid": "app-3"}}
+				]
+			}`
+			events, err = cursor.Next()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(events).To(HaveLen(1))
+			Expect(events[0].AppGuid).To(Equal("app-3"))
+
+			events, err = cursor.Next()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(events).To(BeEmpty())
+			Expect(curler.URLs).To(Equal([]string{firstPage, laterPage, laterPage}))
+		})
+
+		It("does not move the cursor when the request fails", func() {
+			cursor := cloudcontroller.NewAuditEventCursor(c, "some-space", start)
+			curler.errs[firstPage] = errors.New("some-error")
+
+			_, err := cursor.Next()
+			Expect(err).To(MatchError("some-error"))
+
+			curler.errs[firstPage] = nil
+			curler.resps[firstPage] = `{"resources": []}`
+			_, err = cursor.Next()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(curler.URLs).To(Equal([]string{firstPage, firstPage}))
+		})
+	})
+})
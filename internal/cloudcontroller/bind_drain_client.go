@@ -1,18 +1,32 @@
 package cloudcontroller
 
-import "fmt"
+import (
+	"encoding/json"
+	"fmt"
+)
 
 type BindDrainClient struct {
 	c Curler
+	v APIVersion
 }
 
-func NewBindDrainClient(c Curler) *BindDrainClient {
+func NewBindDrainClient(c Curler, v APIVersion) *BindDrainClient {
 	return &BindDrainClient{
 		c: c,
+		v: v,
 	}
 }
 
 func (c *BindDrainClient) BindDrain(appGuid, serviceInstanceGuid string) error {
+	if c.v == V3 {
+		_, err := c.c.Curl(
+			"/v3/service_credential_bindings",
+			"POST",
+			c.buildV3RequestBody(appGuid, serviceInstanceGuid),
+		)
+		return err
+	}
+
 	_, err := c.c.Curl(
 		"/v2/service_bindings",
 		"POST",
@@ -21,6 +35,80 @@ func (c *BindDrainClient) BindDrain(appGuid, serviceInstanceGuid string) error {
 	return err
 }
 
+// UnbindDrain deletes the binding between the app and the service instance.
+// It does nothing if the app is not bound.
+func (c *BindDrainClient) UnbindDrain(appGuid, serviceInstanceGuid string) error {
+	bindingURL, ok, err := c.bindingURL(appGuid, serviceInstanceGuid)
+	if err != nil || !ok {
+		return err
+	}
+
+	_, err = c.c.Curl(bindingURL, "DELETE", "")
+	return err
+}
+
+func (c *BindDrainClient) bindingURL(appGuid, serviceInstanceGuid string) (string, bool, error) {
+	if c.v == V3 {
+		resp, err := c.c.Curl(
+			fmt.Sprintf(
+				"/v3/service_credential_bindings?app_guids=%s&service_instance_guids=%s&type=app",
+				appGuid,
+				serviceInstanceGuid,
+			),
+			"GET",
+			"",
+		)
+		if err != nil {
+			return "", false, err
+		}
+
+		var bindings struct {
+			Resources []struct {
+				Guid string `json:"guid"`
+			} `json:"resources"`
+		}
+		if err := json.Unmarshal(resp, &bindings); err != nil {
+			return "", false, err
+		}
+
+		if len(bindings.Resources) == 0 {
+			return "", false, nil
+		}
+
+		return "/v3/service_credential_bindings/" + bindings.Resources[0].Guid, true, nil
+	}
+
+	resp, err := c.c.Curl(
+		fmt.Sprintf(
+			"/v2/service_bindings?q=app_guid:%s&q=service_instance_guid:%s",
+			appGuid,
+			serviceInstanceGuid,
+		),
+		"GET",
+		"",
+	)
+	if err != nil {
+		return "", false, err
+	}
+
+	var bindings struct {
+		Resources []struct {
+			Metadata struct {
+				Guid string `json:"guid"`
+			} `json:"metadata"`
+		} `json:"resources"`
+	}
+	if err := json.Unmarshal(resp, &bindings); err != nil {
+		return "", false, err
+	}
+
+	if len(bindings.Resources) == 0 {
+		return "", false, nil
+	}
+
+	return "/v2/service_bindings/" + bindings.Resources[0].Metadata.Guid, true, nil
+}
+
 func (c *BindDrainClient) buildRequestBody(appGuid, serviceInstanceGuid string) string {
 	return fmt.Sprintf(
 		`{"service_instance_guid":%q, "app_guid":%q}`,
@@ -28,3 +116,14 @@ func (c *BindDrainClient) buildRequestBody(appGuid, serviceInstanceGuid string)
 		appGuid,
 	)
 }
+
+func (c *BindDrainClient) buildV3RequestBody(appGuid, serviceInstanceGuid string) string {
+	return fmt.Sprintf(`
+	{
+	  "type": "app",
+	  "relationships": {
+	    "app": {"data": {"guid": %q}},
+	    "service_instance": {"data": {"guid": %q}}
+	  }
+	}`, appGuid, serviceInstanceGuid)
+}
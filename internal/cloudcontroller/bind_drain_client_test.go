@@ -17,7 +17,7 @@ var _ = Describe("BindDrainClient", func() {
 
 	BeforeEach(func() {
 		curler = newStubCurler()
-		c = cloudcontroller.NewBindDrainClient(curler)
+		c = cloudcontroller.NewBindDrainClient(curler, cloudcontroller.V2)
 	})
 
 	It("POSTs the correct body", func() {
@@ -39,4 +39,88 @@ var _ = Describe("BindDrainClient", func() {
 		err := c.BindDrain("some-app-guid", "some-drain-guid")
 		Expect(err).To(MatchError("some-error"))
 	})
+
+	Describe("UnbindDrain", func() {
+		var bindingsURL = "/v2/service_bindings?q=app_guid:some-app-guid&q=service_instance_guid:some-drain-guid"
+
+		It("DELETEs the service binding", func() {
+			curler.resps[bindingsURL] = `{"resources": [{"metadata": {"guid": "binding-guid"}}]}`
+
+			err := c.UnbindDrain("some-app-guid", "some-drain-guid")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(curler.URLs).To(Equal([]string{
+				bindingsURL,
+				"/v2/service_bindings/binding-guid",
+			}))
+			Expect(curler.methods).To(Equal([]string{"GET", "DELETE"}))
+		})
+
+		It("does nothing if the app is not bound", func() {
+			curler.resps[bindingsURL] = `{"resources": []}`
+
+			err := c.UnbindDrain("some-app-guid", "some-drain-guid")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(curler.methods).To(Equal([]string{"GET"}))
+		})
+
+		It("returns an error if fetching the binding fails", func() {
+			curler.errs[bindingsURL] = errors.New("some-error")
+
+			err := c.UnbindDrain("some-app-guid", "some-drain-guid")
+			Expect(err).To(MatchError("some-error"))
+		})
+
+		It("returns an error if the DELETE fails", func() {
+			curler.resps[bindingsURL] = `{"resources": [{"metadata": {"guid": "binding-guid"}}]}`
+			curler.errs["/v2/service_bindings/binding-guid"] = errors.New("some-error")
+
+			err := c.UnbindDrain("some-app-guid", "some-drain-guid")
+			Expect(err).To(MatchError("some-error"))
+		})
+	})
+
+	Context("with the v3 API", func() {
+		BeforeEach(func() {
+			c = cloudcontroller.NewBindDrainClient(curler, cloudcontroller.V3)
+		})
+
+		It("POSTs a service credential binding", func() {
+			err := c.BindDrain("some-app-guid", "some-drain-guid")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(curler.methods).To(ConsistOf("POST"))
+			Expect(curler.URLs).To(ConsistOf("/v3/service_credential_bindings"))
+			Expect(curler.bodies).To(ConsistOf(MatchJSON(`
+			{
+			  "type": "app",
+			  "relationships": {
+			    "app": {"data": {"guid": "some-app-guid"}},
+			    "service_instance": {"data": {"guid": "some-drain-guid"}}
+			  }
+			}`,
+			)))
+		})
+
+		It("returns an error if the POST fails", func() {
+			curler.errs["/v3/service_credential_bindings"] = errors.New("some-error")
+			err := c.BindDrain("some-app-guid", "some-drain-guid")
+			Expect(err).To(MatchError("some-error"))
+		})
+
+		It("DELETEs the service credential binding to unbind", func() {
+			bindingsURL := "/v3/service_credential_bindings?app_guids=some-app-guid&service_instance_guids=some-drain-guid&type=app"
+			curler.resps[bindingsURL] = `{"resources": [{"guid": "binding-guid"}]}`
+
+			err := c.UnbindDrain("some-app-guid", "some-drain-guid")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(curler.URLs).To(Equal([]string{
+				bindingsURL,
+				"/v3/service_credential_bindings/binding-guid",
+			}))
+			Expect(curler.methods).To(Equal([]string{"GET", "DELETE"}))
+		})
+	})
 })
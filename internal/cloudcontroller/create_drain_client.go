@@ -6,11 +6,13 @@ import (
 
 type CreateDrainClient struct {
 	c Curler
+	v APIVersion
 }
 
-func NewCreateDrainClient(c Curler) *CreateDrainClient {
+func NewCreateDrainClient(c Curler, v APIVersion) *CreateDrainClient {
 	return &CreateDrainClient{
 		c: c,
+		v: v,
 	}
 }
 
@@ -19,7 +21,16 @@ func (c *CreateDrainClient) CreateDrain(name, url, spaceGuid, drainType string)
 		return fmt.Errorf("invalid drain type: %s", drainType)
 	}
 
-	url = fmt.Sprintf("%s?drain-type=%s", url, drainType)
+	url = DrainURLWithType(url, drainType)
+
+	if c.v == V3 {
+		_, err := c.c.Curl(
+			"/v3/service_instances",
+			"POST",
+			c.buildV3RequestBody(name, url, spaceGuid),
+		)
+		return err
+	}
 
 	_, err := c.c.Curl(
 		"/v2/user_provided_service_instances",
@@ -39,6 +50,24 @@ func (c *CreateDrainClient) buildRequestBody(name, url, spaceGuid string) string
 	}`, url, spaceGuid, name)
 }
 
+func (c *CreateDrainClient) buildV3RequestBody(name, url, spaceGuid string) string {
+	return fmt.Sprintf(`
+	{
+	  "type": "user-provided",
+	  "name": %q,
+	  "syslog_drain_url": %q,
+	  "relationships": {
+	    "space": {"data": {"guid": %q}}
+	  }
+	}`, name, url, spaceGuid)
+}
+
+// DrainURLWithType returns the syslog drain URL that is stored for a drain
+// with the given URL and type.
+func DrainURLWithType(url, drainType string) string {
+	return fmt.Sprintf("%s?drain-type=%s", url, drainType)
+}
+
 func validDrainType(drainType string) bool {
 	switch drainType {
 	case "all", "metrics", "logs":
@@ -20,7 +20,7 @@ var _ = Describe("CreateDrainClient", func() {
 
 	BeforeEach(func() {
 		curler = newStubCurler()
-		c = cloudcontroller.NewCreateDrainClient(curler)
+		c = cloudcontroller.NewCreateDrainClient(curler, cloudcontroller.V2)
 	})
 
 	It("POSTs the request to the Curler", func() {
@@ -66,4 +66,33 @@ var _ = Describe("CreateDrainClient", func() {
 		Expect(err).To(MatchError(fmt.Sprintf("invalid drain type: %s", invalidType)))
 		Expect(curler.URLs).To(BeEmpty())
 	})
+
+	Context("with the v3 API", func() {
+		BeforeEach(func() {
+			c = cloudcontroller.NewCreateDrainClient(curler, cloudcontroller.V3)
+		})
+
+		It("POSTs a user provided service instance", func() {
+			err := c.CreateDrain("some-name", "some-url", "some-space", "all")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(curler.methods).To(ConsistOf("POST"))
+			Expect(curler.URLs).To(ConsistOf("/v3/service_instances"))
+			Expect(curler.bodies).To(ConsistOf(MatchJSON(`
+			{
+			   "type": "user-provided",
+			   "name": "some-name",
+			   "syslog_drain_url": "some-url?drain-type=all",
+			   "relationships": {
+			     "space": {"data": {"guid": "some-space"}}
+			   }
+			}`,
+			)))
+		})
+
+		It("returns an error if the POST fails", func() {
+			curler.errs["/v3/service_instances"] = errors.New("some-error")
+			err := c.CreateDrain("some-name", "some-url", "some-space", "all")
+			Expect(err).To(MatchError("some-error"))
+		})
+	})
 })
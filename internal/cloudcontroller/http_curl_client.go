@@ -17,7 +17,7 @@ type HTTPCurlClient struct {
 	r SaveAndRestager
 	a string
 
-	mu          sync.RWMutex
+	mu          sync.Mutex
 	accessToken string
 }
 
@@ -44,7 +44,7 @@ func NewHTTPCurlClient(apiAddr string, d Doer, f TokenFetcher, r SaveAndRestager
 }
 
 func (c *HTTPCurlClient) Curl(url, method, body string) ([]byte, error) {
-	accToken, _, err := c.token()
+	accToken, err := c.token()
 	if err != nil {
 		return nil, err
 	}
@@ -84,13 +84,10 @@ func (c *HTTPCurlClient) authCurl(URL, method, body, token string) ([]byte, erro
 	}
 
 	if resp.StatusCode == http.StatusUnauthorized {
-		c.accessToken = ""
-		var refToken string
-		_, refToken, err = c.token()
+		err := c.refresh(token)
 		if err != nil {
 			return nil, err
 		}
-		c.r.SaveAndRestage(refToken)
 		return nil, errors.New("unexpected status code 401")
 	}
 
@@ -101,28 +98,52 @@ func (c *HTTPCurlClient) authCurl(URL, method, body, token string) ([]byte, erro
 	return data, nil
 }
 
-func (c *HTTPCurlClient) token() (string, string, error) {
-	c.mu.RLock()
-	accToken := c.accessToken
-	c.mu.RUnlock()
+// token returns the current access token, fetching one if there is none.
+// Fetching holds the lock so that concurrent requests wait for a single
+// fetch.
+func (c *HTTPCurlClient) token() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	if accToken != "" {
-		return accToken, "", nil
+	if c.accessToken != "" {
+		return c.accessToken, nil
 	}
 
-	// We are unprotected via locks here, which can imply that multiple
-	// go-routines are attempting to refresh the token at the same time. Both
-	// will succeed and both will save the token. This is a performance
-	// concern and unlikely. We are going to leave it for now.
-
-	accessToken, refToken, err := c.f.Token()
+	accessToken, _, err := c.f.Token()
 	if err != nil {
-		return "", "", err
+		return "", err
 	}
+	c.accessToken = accessToken
 
+	return c.accessToken, nil
+}
+
+// refresh replaces an access token that was rejected. Requests rejected
+// with the same token at the same time cause a single refresh, and the new
+// refresh token is saved once. Saving happens without the lock since it
+// makes requests of its own.
+func (c *HTTPCurlClient) refresh(rejected string) error {
 	c.mu.Lock()
-	defer c.mu.Unlock()
+	if c.accessToken != rejected {
+		// Another request already refreshed the token.
+		c.mu.Unlock()
+		return nil
+	}
+
+	accessToken, refToken, err := c.f.Token()
+	if err != nil {
+		c.accessToken = ""
+		c.mu.Unlock()
+		return err
+	}
 	c.accessToken = accessToken
+	c.mu.Unlock()
+
+	// Only refresh tokens are rotated by UAA. Client credentials have
+	// nothing to save.
+	if refToken != "" {
+		c.r.SaveAndRestage(refToken)
+	}
 
-	return c.accessToken, refToken, nil
+	return nil
 }
@@ -73,6 +73,43 @@ var _ = Describe("HttpCurlClient", func() {
 		Expect(restager.refreshToken).To(Equal("some-other-ref-token"))
 	})
 
+	It("does not restage without a refresh token", func() {
+		fetcher.tokens = []string{"some-token", "some-other-token"}
+		fetcher.refTokens = []string{"", ""}
+		fetcher.errs = []error{nil, nil}
+
+		doer.statusCode = http.StatusUnauthorized
+		_, err := c.Curl("some-url", "PUT", "some-body")
+		Expect(err).To(MatchError("unexpected status code 401"))
+
+		Expect(fetcher.called).To(Equal(2))
+		Expect(restager.called).To(Equal(0))
+	})
+
+	It("refreshes the token and restages once for concurrent 401s", func() {
+		const requests = 5
+		fetcher.tokens = []string{"some-token", "some-other-token"}
+		fetcher.refTokens = []string{"some-ref-token", "some-other-ref-token"}
+		fetcher.errs = []error{nil, nil}
+
+		unauthorized := newBarrierDoer(requests, http.StatusUnauthorized)
+		c = cloudcontroller.NewHTTPCurlClient("https://api.system-domain.com", unauthorized, fetcher, restager)
+
+		var wg sync.WaitGroup
+		for i := 0; i < requests; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				c.Curl("some-url", "GET", "")
+			}()
+		}
+		wg.Wait()
+
+		Expect(fetcher.called).To(Equal(2))
+		Expect(restager.called).To(Equal(1))
+		Expect(restager.refreshToken).To(Equal("some-other-ref-token"))
+	})
+
 	It("returns an error if the TokenFetcher fails", func() {
 		fetcher.tokens = []string{""}
 		fetcher.refTokens = []string{""}
@@ -174,6 +211,30 @@ func (s *spyDoer) Do(r *http.Request) (*http.Response, error) {
 	}, s.err
 }
 
+// barrierDoer holds every request until n requests are in flight and then
+// answers all of them with the given status code.
+type barrierDoer struct {
+	wg         sync.WaitGroup
+	statusCode int
+}
+
+func newBarrierDoer(n, statusCode int) *barrierDoer {
+	d := &barrierDoer{statusCode: statusCode}
+	d.wg.Add(n)
+
+	return d
+}
+
+func (d *barrierDoer) Do(r *http.Request) (*http.Response, error) {
+	d.wg.Done()
+	d.wg.Wait()
+
+	return &http.Response{
+		StatusCode: d.statusCode,
+		Body:       ioutil.NopCloser(strings.NewReader("")),
+	}, nil
+}
+
 type spyTokenFetcher struct {
 	mu sync.Mutex
 
@@ -216,6 +277,7 @@ func (s *spyTokenFetcher) Token() (string, string, error) {
 
 type spySaveAndRestager struct {
 	mu           sync.Mutex
+	called       int
 	refreshToken string
 }
 
@@ -227,5 +289,6 @@ func (s *spySaveAndRestager) SaveAndRestage(refreshToken string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	s.called++
 	s.refreshToken = refreshToken
 }
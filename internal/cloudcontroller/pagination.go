@@ -0,0 +1,43 @@
+package cloudcontroller
+
+import (
+	"encoding/json"
+	"net/url"
+)
+
+// Link is a v3 pagination link. Cloud Controller returns it as an object
+// with an absolute href, but a bare string path is accepted as well.
+type Link string
+
+func (l *Link) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*l = Link(s)
+		return nil
+	}
+
+	var obj struct {
+		Href string `json:"href"`
+	}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	*l = Link(obj.Href)
+
+	return nil
+}
+
+// Path returns the link relative to the API address so that it can be given
+// to a Curler. An empty Link returns an empty path.
+func (l Link) Path() string {
+	if l == "" {
+		return ""
+	}
+
+	u, err := url.Parse(string(l))
+	if err != nil || u.Host == "" {
+		return string(l)
+	}
+
+	return u.RequestURI()
+}
@@ -0,0 +1,94 @@
+package cloudcontroller
+
+import (
+	"encoding/json"
+	"net/url"
+)
+
+type Space struct {
+	Name    string
+	Guid    string
+	OrgName string
+	OrgGuid string
+}
+
+type SpaceListerClient struct {
+	c Curler
+}
+
+func NewSpaceListerClient(c Curler) *SpaceListerClient {
+	return &SpaceListerClient{
+		c: c,
+	}
+}
+
+// ListSpaces returns every space in the given org that the user can see. An
+// empty orgGuid returns the visible spaces across all orgs.
+func (c *SpaceListerClient) ListSpaces(orgGuid string) ([]Space, error) {
+	params := url.Values{
+		"include":  {"organization"},
+		"order_by": {"name"},
+	}
+	if orgGuid != "" {
+		params.Set("organization_guids", orgGuid)
+	}
+
+	var spaces []Space
+	orgNames := make(map[string]string)
+	path := "/v3/spaces?" + params.Encode()
+	for path != "" {
+		resp, err := c.c.Curl(path, "GET", "")
+		if err != nil {
+			return nil, err
+		}
+
+		var spacesResp spacesResponse
+		err = json.Unmarshal(resp, &spacesResp)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, o := range spacesResp.Included.Organizations {
+			orgNames[o.Guid] = o.Name
+		}
+
+		for _, s := range spacesResp.Resources {
+			spaces = append(spaces, Space{
+				Name:    s.Name,
+				Guid:    s.Guid,
+				OrgGuid: s.Relationships.Organization.Data.Guid,
+			})
+		}
+
+		path = spacesResp.Pagination.Next.Path()
+	}
+
+	for i, s := range spaces {
+		spaces[i].OrgName = orgNames[s.OrgGuid]
+	}
+
+	return spaces, nil
+}
+
+type spacesResponse struct {
+	Pagination struct {
+		Next Link `json:"next"`
+	} `json:"pagination"`
+	Resources []struct {
+		Guid          string `json:"guid"`
+		Name          string `json:"name"`
+		Relationships struct {
+			Organization struct {
+				Data struct {
+					Guid string `json:"guid"`
+				} `json:"data"`
+			} `json:"organization"`
+		} `json:"relationships"`
+	} `json:"resources"`
+	Included struct {
+		Organizations []struct {
+			Guid string `json:"guid"`
+			Name string `json:"name"`
+		} `json:"organizations"`
+	} `json:"included"`
+}
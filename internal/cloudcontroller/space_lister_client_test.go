@@ -0,0 +1,104 @@
+package cloudcontroller_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/cf-drain-cli/internal/cloudcontroller"
+)
+
+var _ = Describe("SpaceListerClient", func() {
+	var (
+		curler *stubCurler
+		c      *cloudcontroller.SpaceListerClient
+	)
+
+	BeforeEach(func() {
+		curler = newStubCurler()
+		c = cloudcontroller.NewSpaceListerClient(curler)
+	})
+
+	It("requests every space in the org and follows pagination", func() {
+		curler.resps["/v3/spaces?include=organization&order_by=name&organization_guids=org-guid"] = `{
+			"pagination": {
+				"next": {"href": "https://api.example.com/v3/spaces?include=organization&order_by=name&organization_guids=org-guid&page=2"}
+			},
+			"resources": [
+				{
+					"guid": "space-1-guid",
+					"name": "space-1",
+					"relationships": {"organization": {"data": {"guid": "org-guid"}}}
+				}
+			],
+			"included": {
+				"organizations": [{"guid": "org-guid", "name": "org-1"}]
+			}
+		}`
+		curler.resps["/v3/spaces?include=organization&order_by=name&organization_guids=org-guid&page=2"] = `{
+			"pagination": {"next": null},
+			"resources": [
+				{
+					"guid": "space-2-guid",
+					"name": "space-2",
+					"relationships": {"organization": {"data": {"guid": "org-guid"}}}
+				}
+			],
+			"included": {
+				"organizations": [{"guid": "org-guid", "name": "org-1"}]
+			}
+		}`
+
+		spaces, err := c.ListSpaces("org-guid")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(curler.methods).To(ConsistOf("GET", "GET"))
+		Expect(spaces).To(Equal([]cloudcontroller.Space{
+			{Name: "space-1", Guid: "space-1-guid", OrgName: "org-1", OrgGuid: "org-guid"},
+			{Name: "space-2", Guid: "space-2-guid", OrgName: "org-1", OrgGuid: "org-guid"},
+		}))
+	})
+
+	It("requests every visible space without an org", func() {
+		curler.resps["/v3/spaces?include=organization&order_by=name"] = `{
+			"pagination": {"next": null},
+			"resources": [
+				{
+					"guid": "space-1-guid",
+					"name": "space-1",
+					"relationships": {"organization": {"data": {"guid": "org-1-guid"}}}
+				},
+				{
+					"guid": "space-2-guid",
+					"name": "space-2",
+					"relationships": {"organization": {"data": {"guid": "org-2-guid"}}}
+				}
+			],
+			"included": {
+				"organizations": [
+					{"guid": "org-1-guid", "name": "org-1"},
+					{"guid": "org-2-guid", "name": "org-2"}
+				]
+			}
+		}`
+
+		spaces, err := c.ListSpaces("")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(spaces).To(Equal([]cloudcontroller.Space{
+			{Name: "space-1", Guid: "space-1-guid", OrgName: "org-1", OrgGuid: "org-1-guid"},
+			{Name: "space-2", Guid: "space-2-guid", OrgName: "org-2", OrgGuid: "org-2-guid"},
+		}))
+	})
+
+	It("returns an error if the GET fails", func() {
+		curler.errs["/v3/spaces?include=organization&order_by=name"] = errors.New("some-error")
+		_, err := c.ListSpaces("")
+		Expect(err).To(MatchError("some-error"))
+	})
+
+	It("returns an error if the JSON is invalid", func() {
+		curler.resps["/v3/spaces?include=organization&order_by=name"] = `invalid`
+		_, err := c.ListSpaces("")
+		Expect(err).To(HaveOccurred())
+	})
+})
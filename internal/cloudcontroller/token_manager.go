@@ -1,10 +1,16 @@
 package cloudcontroller
 
+import (
+	"fmt"
+	"sync"
+)
+
 type AuthCurler interface {
 	Curl(url, method, body string) ([]byte, error)
 }
 
 type UAAClient interface {
+	GetAuthToken(clientID, clientSecret string, insecureSkipVerify bool) (string, error)
 	GetRefreshToken(clientID, refreshToken string, insecureSkipVerify bool) (string, string, error)
 }
 
@@ -12,13 +18,18 @@ type Logger interface {
 	Fatalf(format string, v ...interface{})
 }
 
+// TokenManager fetches access tokens from UAA. With a client secret it uses
+// the client credentials grant. Otherwise it falls back to a user's refresh
+// token, which UAA rotates on every use.
 type TokenManager struct {
+	mu sync.Mutex
+
 	uaa                UAAClient
 	clientID           string
+	clientSecret       string
 	refreshToken       string
 	appGUID            string
 	insecureSkipVerify bool
-	log                Logger
 }
 
 func NewTokenManager(
@@ -27,23 +38,53 @@ func NewTokenManager(
 	initialRefreshToken string,
 	appGUID string,
 	skipCertVerify bool,
-	log Logger,
-
+	opts ...TokenManagerOption,
 ) *TokenManager {
-	return &TokenManager{
+	m := &TokenManager{
 		uaa:                uaa,
 		clientID:           clientID,
 		refreshToken:       initialRefreshToken,
 		appGUID:            appGUID,
 		insecureSkipVerify: skipCertVerify,
-		log:                log,
+	}
+
+	for _, o := range opts {
+		o(m)
+	}
+
+	return m
+}
+
+type TokenManagerOption func(m *TokenManager)
+
+// WithClientSecret makes the TokenManager authenticate as the UAA client
+// itself instead of using a refresh token. An empty secret keeps the
+// refresh token.
+func WithClientSecret(secret string) TokenManagerOption {
+	return func(m *TokenManager) {
+		m.clientSecret = secret
 	}
 }
 
+// Token fetches a new access token from UAA. An error is returned rather
+// than exiting so that a broken token refresh shows up in the space drain's
+// health. The returned refresh token is empty for client credentials.
 func (m *TokenManager) Token() (string, string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.clientSecret != "" {
+		accToken, err := m.uaa.GetAuthToken(m.clientID, m.clientSecret, m.insecureSkipVerify)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to fetch token from UAA: %s", err)
+		}
+
+		return accToken, "", nil
+	}
+
 	refToken, accToken, err := m.uaa.GetRefreshToken(m.clientID, m.refreshToken, m.insecureSkipVerify)
 	if err != nil {
-		m.log.Fatalf("Failed to fetch tokens from UAA: %s", err)
+		return "", "", fmt.Errorf("failed to fetch tokens from UAA: %s", err)
 	}
 	m.refreshToken = refToken
 
@@ -11,16 +11,14 @@ import (
 
 var _ = Describe("TokenManager", func() {
 	var (
-		uaa        *spyUAAClient
-		m          *cloudcontroller.TokenManager
-		stubLogger *stubLogger
+		uaa *spyUAAClient
+		m   *cloudcontroller.TokenManager
 	)
 
 	BeforeEach(func() {
 		uaa = &spyUAAClient{}
 		uaa.respAccessToken = "access-token"
 		uaa.respRefreshToken = "new-refresh-token"
-		stubLogger = newStubLogger()
 
 		m = cloudcontroller.NewTokenManager(
 			uaa,
@@ -28,7 +26,6 @@ var _ = Describe("TokenManager", func() {
 			"initial-refresh-token",
 			"app-guid",
 			false,
-			stubLogger,
 		)
 	})
 
@@ -50,7 +47,6 @@ var _ = Describe("TokenManager", func() {
 			"refresh-token",
 			"appguid",
 			true,
-			stubLogger,
 		)
 		m.Token()
 		Expect(uaa.reqSkipCertVerify).To(BeTrue())
@@ -61,21 +57,71 @@ var _ = Describe("TokenManager", func() {
 			"refresh-token",
 			"appguid",
 			false,
-			stubLogger,
 		)
 		m.Token()
 		Expect(uaa.reqSkipCertVerify).To(BeFalse())
 	})
 
+	Context("with a client secret", func() {
+		BeforeEach(func() {
+			m = cloudcontroller.NewTokenManager(
+				uaa,
+				"client-id",
+				"",
+				"app-guid",
+				true,
+				cloudcontroller.WithClientSecret("client-secret"),
+			)
+		})
+
+		It("uses client credentials to fetch an access token", func() {
+			token, refToken, err := m.Token()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(token).To(Equal("access-token"))
+			Expect(refToken).To(BeEmpty())
+
+			Expect(uaa.reqClientID).To(Equal("client-id"))
+			Expect(uaa.reqClientSecret).To(Equal("client-secret"))
+			Expect(uaa.reqSkipCertVerify).To(BeTrue())
+			Expect(uaa.reqRefreshToken).To(BeEmpty())
+		})
+
+		It("prefers client credentials over a refresh token", func() {
+			m = cloudcontroller.NewTokenManager(
+				uaa,
+				"client-id",
+				"initial-refresh-token",
+				"app-guid",
+				false,
+				cloudcontroller.WithClientSecret("client-secret"),
+			)
+
+			_, refToken, err := m.Token()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(refToken).To(BeEmpty())
+			Expect(uaa.reqClientSecret).To(Equal("client-secret"))
+			Expect(uaa.reqRefreshToken).To(BeEmpty())
+		})
+
+		It("returns an error if UAA fails", func() {
+			uaa.respError = errors.New("uaa-error")
+			_, _, err := m.Token()
+			Expect(err).To(MatchError("failed to fetch token from UAA: uaa-error"))
+		})
+	})
+
 	It("returns an error if UAA fails", func() {
 		uaa.respError = errors.New("uaa-error")
-		Expect(func() { m.Token() }).To(Panic())
+		_, _, err := m.Token()
+		Expect(err).To(MatchError("failed to fetch tokens from UAA: uaa-error"))
 	})
 
 	It("does not overwrite the refresh token if GetRefreshToken fails", func() {
 		uaa.respError = errors.New("Failed to fetch tokens from UAA")
-		Expect(func() { m.Token() }).To(Panic())
-		Expect(stubLogger.called).To(Equal(1))
+		_, _, err := m.Token()
+		Expect(err).To(HaveOccurred())
 
 		// recovery
 		uaa.respError = nil
@@ -87,6 +133,7 @@ var _ = Describe("TokenManager", func() {
 
 type spyUAAClient struct {
 	reqClientID       string
+	reqClientSecret   string
 	reqRefreshToken   string
 	reqSkipCertVerify bool
 
@@ -95,6 +142,14 @@ type spyUAAClient struct {
 	respError        error
 }
 
+func (s *spyUAAClient) GetAuthToken(clientID, clientSecret string, insecureSkipVerify bool) (string, error) {
+	s.reqClientID = clientID
+	s.reqClientSecret = clientSecret
+	s.reqSkipCertVerify = insecureSkipVerify
+
+	return s.respAccessToken, s.respError
+}
+
 func (s *spyUAAClient) GetRefreshToken(clientID, refreshToken string, insecureSkipVerify bool) (string, string, error) {
 	s.reqClientID = clientID
 	s.reqRefreshToken = refreshToken
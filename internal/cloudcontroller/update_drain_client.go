@@ -0,0 +1,43 @@
+package cloudcontroller
+
+import (
+	"fmt"
+)
+
+type UpdateDrainClient struct {
+	c Curler
+	v APIVersion
+}
+
+func NewUpdateDrainClient(c Curler, v APIVersion) *UpdateDrainClient {
+	return &UpdateDrainClient{
+		c: c,
+		v: v,
+	}
+}
+
+// UpdateDrain changes the syslog drain URL of an existing user provided
+// service instance. Its bindings are kept.
+func (c *UpdateDrainClient) UpdateDrain(serviceInstanceGuid, url, drainType string) error {
+	if !validDrainType(drainType) {
+		return fmt.Errorf("invalid drain type: %s", drainType)
+	}
+
+	body := fmt.Sprintf(`{"syslog_drain_url": %q}`, DrainURLWithType(url, drainType))
+
+	if c.v == V3 {
+		_, err := c.c.Curl(
+			fmt.Sprintf("/v3/service_instances/%s", serviceInstanceGuid),
+			"PATCH",
+			body,
+		)
+		return err
+	}
+
+	_, err := c.c.Curl(
+		fmt.Sprintf("/v2/user_provided_service_instances/%s", serviceInstanceGuid),
+		"PUT",
+		body,
+	)
+	return err
+}
@@ -0,0 +1,72 @@
+package cloudcontroller_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/cf-drain-cli/internal/cloudcontroller"
+)
+
+var _ = Describe("UpdateDrainClient", func() {
+	var (
+		curler *stubCurler
+		c      *cloudcontroller.UpdateDrainClient
+	)
+
+	BeforeEach(func() {
+		curler = newStubCurler()
+		c = cloudcontroller.NewUpdateDrainClient(curler, cloudcontroller.V2)
+	})
+
+	It("PUTs the new drain URL", func() {
+		err := c.UpdateDrain("some-guid", "syslog://some-url", "metrics")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(curler.methods).To(ConsistOf("PUT"))
+		Expect(curler.URLs).To(ConsistOf("/v2/user_provided_service_instances/some-guid"))
+		Expect(curler.bodies).To(ConsistOf(MatchJSON(`
+		{
+		  "syslog_drain_url": "syslog://some-url?drain-type=metrics"
+		}`,
+		)))
+	})
+
+	It("returns an error for an invalid drain type", func() {
+		err := c.UpdateDrain("some-guid", "syslog://some-url", "bogus")
+		Expect(err).To(MatchError("invalid drain type: bogus"))
+		Expect(curler.URLs).To(BeEmpty())
+	})
+
+	It("returns an error if the PUT fails", func() {
+		curler.errs["/v2/user_provided_service_instances/some-guid"] = errors.New("some-error")
+		err := c.UpdateDrain("some-guid", "syslog://some-url", "all")
+		Expect(err).To(MatchError("some-error"))
+	})
+
+	Context("with the v3 API", func() {
+		BeforeEach(func() {
+			c = cloudcontroller.NewUpdateDrainClient(curler, cloudcontroller.V3)
+		})
+
+		It("PATCHes the service instance", func() {
+			err := c.UpdateDrain("some-guid", "syslog://some-url", "logs")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(curler.methods).To(ConsistOf("PATCH"))
+			Expect(curler.URLs).To(ConsistOf("/v3/service_instances/some-guid"))
+			Expect(curler.bodies).To(ConsistOf(MatchJSON(`
+			{
+			  "syslog_drain_url": "syslog://some-url?drain-type=logs"
+			}`,
+			)))
+		})
+
+		It("returns an error if the PATCH fails", func() {
+			curler.errs["/v3/service_instances/some-guid"] = errors.New("some-error")
+			err := c.UpdateDrain("some-guid", "syslog://some-url", "all")
+			Expect(err).To(MatchError("some-error"))
+		})
+	})
+})
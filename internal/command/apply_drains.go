@@ -0,0 +1,245 @@
+package command
+
+import (
+	"net/url"
+	"strings"
+
+	"code.cloudfoundry.org/cf-drain-cli/internal/cloudcontroller"
+	"code.cloudfoundry.org/cf-drain-cli/internal/drain"
+	"code.cloudfoundry.org/cli/plugin"
+	flags "github.com/jessevdk/go-flags"
+)
+
+type AppLister interface {
+	ListAppsWithSelector(spaceGUID, selector string) ([]cloudcontroller.App, error)
+}
+
+type applyDrainsOpts struct {
+	File     string   `long:"file" short:"f" required:"true"`
+	Prune    bool     `long:"prune"`
+	Vars     []string `long:"var"`
+	VarsFile string   `long:"vars-file"`
+}
+
+// ApplyDrains converges the drains in the current space to the drains
+// described by a manifest. Drains are created or have their URL updated, and
+// apps are bound or unbound so that each drain is bound to exactly the apps
+// listed for it. With --prune drains that are not in the manifest are
+// deleted, except for drains managed by a space drain app. Placeholders in
+// drain URLs are filled in from --var and --vars-file.
+func ApplyDrains(
+	cli plugin.CliConnection,
+	args []string,
+	log Logger,
+	df DrainFetcher,
+	al AppLister,
+	ef EnvFetcher,
+) {
+	opts := applyDrainsOpts{}
+
+	parser := flags.NewParser(&opts, flags.HelpFlag|flags.PassDoubleDash)
+	args, err := parser.ParseArgs(args)
+	if err != nil {
+		log.Fatalf("%s", err)
+	}
+
+	if len(args) != 0 {
+		log.Fatalf("Invalid arguments, expected 0, got %d.", len(args))
+	}
+
+	vars, err := readVars(opts.VarsFile, opts.Vars)
+	if err != nil {
+		log.Fatalf("Invalid vars: %s", err)
+	}
+
+	m, err := readDrainManifest(opts.File, vars)
+	if err != nil {
+		log.Fatalf("Invalid drain manifest: %s", err)
+	}
+
+	space := currentSpace(cli, log)
+
+	existing, err := df.Drains(space.Guid)
+	if err != nil {
+		log.Fatalf("Failed to fetch drains: %s", err)
+	}
+
+	for _, d := range m.Drains {
+		drainURL, err := d.drainURL()
+		if err != nil {
+			log.Fatalf("%s", err)
+		}
+
+		apps := manifestApps(al, space.Guid, d, log)
+
+		current, ok := findDrain(existing, d.Name)
+		switch {
+		case !ok:
+			log.Printf("Creating drain %s...", d.Name)
+			runCommand(cli, log, "create-user-provided-service", d.Name, "-l", drainURL)
+		case !sameDrainURL(current.DrainURL, drainURL):
+			log.Printf("Updating drain %s...", d.Name)
+			runCommand(cli, log, "update-user-provided-service", d.Name, "-l", drainURL)
+		}
+
+		for _, app := range apps {
+			if containsString(current.Apps, app) {
+				continue
+			}
+
+			log.Printf("Binding %s to drain %s...", app, d.Name)
+			runCommand(cli, log, "bind-service", app, d.Name)
+		}
+
+		for _, app := range current.Apps {
+			if containsString(apps, app) {
+				continue
+			}
+
+			log.Printf("Unbinding %s from drain %s...", app, d.Name)
+			runCommand(cli, log, "unbind-service", app, d.Name)
+		}
+	}
+
+	if !opts.Prune {
+		return
+	}
+
+	var managed map[string]string
+	for _, d := range existing {
+		if _, ok := findManifestDrain(m, d.Name); ok {
+			continue
+		}
+
+		// The space drain app would recreate the drain, so it is left to
+		// delete-drain-space.
+		if managed == nil {
+			managed = spaceDrains(space.Guid, al, ef, log)
+		}
+		if app, ok := managed[d.Name]; ok {
+			log.Printf("Skipping drain %s, it is managed by space drain %s.", d.Name, app)
+			continue
+		}
+
+		log.Printf("Deleting drain %s...", d.Name)
+		for _, app := range d.Apps {
+			runCommand(cli, log, "unbind-service", app, d.Name)
+		}
+		runCommand(cli, log, "delete-service", d.Name, "-f")
+	}
+}
+
+// manifestApps returns the names of the apps listed for the drain along with
+// the apps that match any of its label selectors.
+func manifestApps(al AppLister, spaceGuid string, d manifestDrain, log Logger) []string {
+	apps := uniqueStrings(d.Apps)
+	for _, selector := range d.Selectors {
+		selected, err := al.ListAppsWithSelector(spaceGuid, selector)
+		if err != nil {
+			log.Fatalf("Failed to list apps for selector %s: %s", selector, err)
+		}
+
+		for _, app := range selected {
+			if !containsString(apps, app.Name) {
+				apps = append(apps, app.Name)
+			}
+		}
+	}
+
+	return apps
+}
+
+// spaceDrains returns the names of the drains managed by the space drain
+// apps in the space, mapped to the name of the app managing each.
+func spaceDrains(spaceGuid string, al AppLister, ef EnvFetcher, log Logger) map[string]string {
+	apps, err := al.ListAppsWithSelector(spaceGuid, "")
+	if err != nil {
+		log.Fatalf("Failed to list apps: %s", err)
+	}
+
+	managed := make(map[string]string)
+	for _, app := range apps {
+		envs, err := ef.EnvVars(app.Guid)
+		if err != nil {
+			log.Fatalf("Failed to read env variables for %s: %s", app.Name, err)
+		}
+
+		if envs["DRAIN_SCOPE"] == "space" {
+			managed[envs["DRAIN_NAME"]] = app.Name
+		}
+	}
+
+	return managed
+}
+
+// sameDrainURL reports whether two drain URLs send the same logs to the
+// same place. Query parameters are compared regardless of their order, and
+// drain-type=logs is the same as no drain type.
+func sameDrainURL(a, b string) bool {
+	return normalizeDrainURL(a) == normalizeDrainURL(b)
+}
+
+func normalizeDrainURL(s string) string {
+	u, err := url.Parse(s)
+	if err != nil {
+		return s
+	}
+
+	q := u.Query()
+	if dt := q.Get("drain-type"); dt == "" || dt == "logs" {
+		q.Del("drain-type")
+	}
+	u.RawQuery = q.Encode()
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+
+	return u.String()
+}
+
+func runCommand(cli plugin.CliConnection, log Logger, args ...string) {
+	_, err := cli.CliCommand(args...)
+	if err != nil {
+		log.Fatalf("%s", err)
+	}
+}
+
+func findDrain(drains []drain.Drain, name string) (drain.Drain, bool) {
+	for _, d := range drains {
+		if d.Name == name {
+			return d, true
+		}
+	}
+
+	return drain.Drain{}, false
+}
+
+func findManifestDrain(m drainManifest, name string) (manifestDrain, bool) {
+	for _, d := range m.Drains {
+		if d.Name == name {
+			return d, true
+		}
+	}
+
+	return manifestDrain{}, false
+}
+
+func containsString(strs []string, s string) bool {
+	for _, ss := range strs {
+		if ss == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+func uniqueStrings(strs []string) []string {
+	var unique []string
+	for _, s := range strs {
+		if !containsString(unique, s) {
+			unique = append(unique, s)
+		}
+	}
+
+	return unique
+}
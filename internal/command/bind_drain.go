@@ -1,17 +1,41 @@
 package command
 
 import (
+	"path"
+
 	"code.cloudfoundry.org/cf-drain-cli/internal/drain"
 	"code.cloudfoundry.org/cli/plugin"
+	flags "github.com/jessevdk/go-flags"
 )
 
-func BindDrain(cli plugin.CliConnection, df DrainFetcher, args []string, log Logger) {
-	if len(args) != 2 {
-		log.Fatalf("Invalid arguments, expected 2, got %d.", len(args))
+type bindDrainOpts struct {
+	Apps     string `long:"apps"`
+	Selector string `long:"selector"`
+}
+
+// BindDrain binds apps to an existing drain. The apps are given by name, by a
+// glob matched against the app names in the space, or by a label selector.
+// A failure to bind one app does not stop the others from being bound.
+func BindDrain(cli plugin.CliConnection, df DrainFetcher, al AppLister, args []string, log Logger) {
+	opts := bindDrainOpts{}
+
+	parser := flags.NewParser(&opts, flags.HelpFlag|flags.PassDoubleDash)
+	args, err := parser.ParseArgs(args)
+	if err != nil {
+		log.Fatalf("%s", err)
 	}
 
-	appName := args[0]
-	drainName := args[1]
+	minArgs := 2
+	if opts.Apps != "" || opts.Selector != "" {
+		minArgs = 1
+	}
+
+	if len(args) < minArgs {
+		log.Fatalf("Invalid arguments, expected at least %d, got %d.", minArgs, len(args))
+	}
+
+	appNames := uniqueStrings(args[:len(args)-1])
+	drainName := args[len(args)-1]
 
 	space, err := cli.GetCurrentSpace()
 	if err != nil {
@@ -27,9 +51,54 @@ func BindDrain(cli plugin.CliConnection, df DrainFetcher, args []string, log Log
 		log.Fatalf("%s is not a valid drain.", drainName)
 	}
 
-	_, err = cli.CliCommand("bind-service", appName, drainName)
-	if err != nil {
-		log.Fatalf("%s", err)
+	if opts.Apps != "" {
+		if _, err := path.Match(opts.Apps, ""); err != nil {
+			log.Fatalf("Invalid app pattern %s: %s", opts.Apps, err)
+		}
+
+		apps, err := cli.GetApps()
+		if err != nil {
+			log.Fatalf("%s", err)
+		}
+
+		for _, app := range apps {
+			if ok, _ := path.Match(opts.Apps, app.Name); ok && !containsString(appNames, app.Name) {
+				appNames = append(appNames, app.Name)
+			}
+		}
+	}
+
+	if opts.Selector != "" {
+		apps, err := al.ListAppsWithSelector(space.Guid, opts.Selector)
+		if err != nil {
+			log.Fatalf("Failed to list apps for selector %s: %s", opts.Selector, err)
+		}
+
+		for _, app := range apps {
+			if !containsString(appNames, app.Name) {
+				appNames = append(appNames, app.Name)
+			}
+		}
+	}
+
+	if len(appNames) == 0 {
+		log.Fatalf("No apps found to bind to %s.", drainName)
+	}
+
+	var failed int
+	for _, appName := range appNames {
+		_, err = cli.CliCommand("bind-service", appName, drainName)
+		if err != nil {
+			failed++
+			log.Printf("Failed to bind %s to %s: %s", appName, drainName, err)
+			continue
+		}
+
+		log.Printf("Bound %s to %s.", appName, drainName)
+	}
+
+	if failed > 0 {
+		log.Fatalf("Failed to bind %d of %d apps to %s.", failed, len(appNames), drainName)
 	}
 }
 
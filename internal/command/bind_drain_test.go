@@ -3,8 +3,10 @@ package command_test
 import (
 	"errors"
 
+	"code.cloudfoundry.org/cf-drain-cli/internal/cloudcontroller"
 	"code.cloudfoundry.org/cf-drain-cli/internal/command"
 	"code.cloudfoundry.org/cf-drain-cli/internal/drain"
+	"code.cloudfoundry.org/cli/plugin/models"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -15,6 +17,7 @@ var _ = Describe("BindDrain", func() {
 		logger       *stubLogger
 		cli          *stubCliConnection
 		drainFetcher *stubDrainFetcher
+		appLister    *stubAppLister
 	)
 
 	BeforeEach(func() {
@@ -25,12 +28,13 @@ var _ = Describe("BindDrain", func() {
 		drainFetcher.drains = []drain.Drain{
 			{Name: "drain-name"},
 		}
+		appLister = newStubAppLister()
 	})
 
 	It("calls bind-service with the given app name and service", func() {
 		args := []string{"app-name", "drain-name"}
 
-		command.BindDrain(cli, drainFetcher, args, logger)
+		command.BindDrain(cli, drainFetcher, appLister, args, logger)
 
 		Expect(cli.cliCommandArgs).To(HaveLen(1))
 		Expect(cli.cliCommandArgs[0]).To(Equal([]string{
@@ -38,36 +42,137 @@ var _ = Describe("BindDrain", func() {
 		}))
 	})
 
+	It("binds several apps", func() {
+		args := []string{"app-1", "app-2", "app-1", "drain-name"}
+
+		command.BindDrain(cli, drainFetcher, appLister, args, logger)
+
+		Expect(cli.cliCommandArgs).To(Equal([][]string{
+			{"bind-service", "app-1", "drain-name"},
+			{"bind-service", "app-2", "drain-name"},
+		}))
+		Expect(logger.printfMessages).To(Equal([]string{
+			"Bound app-1 to drain-name.",
+			"Bound app-2 to drain-name.",
+		}))
+	})
+
+	It("binds apps that match a glob", func() {
+		cli.getAppsApps = []plugin_models.GetAppsModel{
+			{Name: "payments-api"},
+			{Name: "orders-api"},
+			{Name: "payments-worker"},
+		}
+		args := []string{"--apps", "payments-*", "drain-name"}
+
+		command.BindDrain(cli, drainFetcher, appLister, args, logger)
+
+		Expect(cli.cliCommandArgs).To(Equal([][]string{
+			{"bind-service", "payments-api", "drain-name"},
+			{"bind-service", "payments-worker", "drain-name"},
+		}))
+	})
+
+	It("binds apps that match a label selector", func() {
+		appLister.apps = map[string][]cloudcontroller.App{
+			"team=payments": {{Name: "app-1"}, {Name: "app-2"}},
+		}
+		args := []string{"app-1", "--selector", "team=payments", "drain-name"}
+
+		command.BindDrain(cli, drainFetcher, appLister, args, logger)
+
+		Expect(appLister.spaceGuids).To(Equal([]string{"space-guid"}))
+		Expect(cli.cliCommandArgs).To(Equal([][]string{
+			{"bind-service", "app-1", "drain-name"},
+			{"bind-service", "app-2", "drain-name"},
+		}))
+	})
+
+	It("keeps binding apps after a failure and reports the failures", func() {
+		cli.bindServiceErrors["app-1"] = errors.New("unable to bind")
+		args := []string{"app-1", "app-2", "drain-name"}
+
+		Expect(func() {
+			command.BindDrain(cli, drainFetcher, appLister, args, logger)
+		}).To(Panic())
+
+		Expect(cli.cliCommandArgs).To(HaveLen(2))
+		Expect(logger.printfMessages).To(Equal([]string{
+			"Failed to bind app-1 to drain-name: unable to bind",
+			"Bound app-2 to drain-name.",
+		}))
+		Expect(logger.fatalfMessage).To(Equal("Failed to bind 1 of 2 apps to drain-name."))
+	})
+
 	It("fatally logs if it fails to bind to service", func() {
 		cli.bindServiceError = errors.New("unable to bind")
 		args := []string{"app-name", "drain-name"}
 
 		Expect(func() {
-			command.BindDrain(cli, drainFetcher, args, logger)
+			command.BindDrain(cli, drainFetcher, appLister, args, logger)
+		}).To(Panic())
+		Expect(logger.printfMessages).To(ContainElement("Failed to bind app-name to drain-name: unable to bind"))
+		Expect(logger.fatalfMessage).To(Equal("Failed to bind 1 of 1 apps to drain-name."))
+	})
+
+	It("expects to receive an app and a drain", func() {
+		args := []string{"drain-name"}
+
+		Expect(func() {
+			command.BindDrain(cli, drainFetcher, appLister, args, logger)
+		}).To(Panic())
+		Expect(logger.fatalfMessage).To(Equal("Invalid arguments, expected at least 2, got 1."))
+
+		args = []string{"--apps", "app-*"}
+		Expect(func() {
+			command.BindDrain(cli, drainFetcher, appLister, args, logger)
+		}).To(Panic())
+		Expect(logger.fatalfMessage).To(Equal("Invalid arguments, expected at least 1, got 0."))
+	})
+
+	It("fatally logs if no apps match", func() {
+		args := []string{"--apps", "app-*", "drain-name"}
+
+		Expect(func() {
+			command.BindDrain(cli, drainFetcher, appLister, args, logger)
 		}).To(Panic())
-		Expect(logger.fatalfMessage).To(Equal("unable to bind"))
+		Expect(logger.fatalfMessage).To(Equal("No apps found to bind to drain-name."))
 	})
 
-	It("expects to receive 2 arguments", func() {
-		args := []string{"app-name", "drain-name", "extra"}
+	It("fatally logs for an invalid glob", func() {
+		args := []string{"--apps", "app-[", "drain-name"}
 
 		Expect(func() {
-			command.BindDrain(cli, drainFetcher, args, logger)
+			command.BindDrain(cli, drainFetcher, appLister, args, logger)
 		}).To(Panic())
-		Expect(logger.fatalfMessage).To(Equal("Invalid arguments, expected 2, got 3."))
+		Expect(logger.fatalfMessage).To(HavePrefix("Invalid app pattern app-["))
+	})
+
+	It("fatally logs if it fails to list apps", func() {
+		cli.getAppsError = errors.New("Failed to get apps.")
+		args := []string{"--apps", "app-*", "drain-name"}
+
+		Expect(func() {
+			command.BindDrain(cli, drainFetcher, appLister, args, logger)
+		}).To(Panic())
+		Expect(logger.fatalfMessage).To(Equal("Failed to get apps."))
+	})
+
+	It("fatally logs if it fails to list apps for the selector", func() {
+		appLister.err = errors.New("some-error")
+		args := []string{"--selector", "team=payments", "drain-name"}
 
-		args = []string{"app-name"}
 		Expect(func() {
-			command.BindDrain(cli, drainFetcher, args, logger)
+			command.BindDrain(cli, drainFetcher, appLister, args, logger)
 		}).To(Panic())
-		Expect(logger.fatalfMessage).To(Equal("Invalid arguments, expected 2, got 1."))
+		Expect(logger.fatalfMessage).To(Equal("Failed to list apps for selector team=payments: some-error"))
 	})
 
 	It("fatally logs if the drain does not exist", func() {
 		args := []string{"app-name", "unknown-drain-name"}
 
 		Expect(func() {
-			command.BindDrain(cli, drainFetcher, args, logger)
+			command.BindDrain(cli, drainFetcher, appLister, args, logger)
 		}).To(Panic())
 		Expect(logger.fatalfMessage).To(Equal("unknown-drain-name is not a valid drain."))
 	})
@@ -77,7 +182,7 @@ var _ = Describe("BindDrain", func() {
 		drainFetcher.err = errors.New("Failed to fetch drains.")
 
 		Expect(func() {
-			command.BindDrain(cli, drainFetcher, args, logger)
+			command.BindDrain(cli, drainFetcher, appLister, args, logger)
 		}).To(Panic())
 		Expect(logger.fatalfMessage).To(Equal("Failed to fetch drains."))
 	})
@@ -87,7 +192,7 @@ var _ = Describe("BindDrain", func() {
 		cli.currentSpaceError = errors.New("Failed to get space.")
 
 		Expect(func() {
-			command.BindDrain(cli, drainFetcher, args, logger)
+			command.BindDrain(cli, drainFetcher, appLister, args, logger)
 		}).To(Panic())
 		Expect(logger.fatalfMessage).To(Equal("Failed to get space."))
 	})
@@ -30,6 +30,8 @@ type stubCliConnection struct {
 	getAppGuid    string
 	getAppError   error
 	getAppEnvVars map[string]interface{}
+	getAppState   string
+	getAppRoutes  []plugin_models.GetApp_RouteSummary
 
 	getServicesName  string
 	getServicesError error
@@ -45,11 +47,14 @@ type stubCliConnection struct {
 	cliCommandArgs     [][]string
 	createUserError    error
 	createServiceError error
+	updateServiceError error
 	bindServiceError   error
+	bindServiceErrors  map[string]error
 	unbindServiceError error
 	deleteServiceError error
 	pushAppError       error
 	startAppError      error
+	restartAppError    error
 	deleteAppError     error
 
 	currentSpaceName  string
@@ -58,6 +63,10 @@ type stubCliConnection struct {
 	currentOrgName    string
 	currentOrgError   error
 
+	getOrgName  string
+	getOrgGuid  string
+	getOrgError error
+
 	apiEndpoint      string
 	apiEndpointError error
 
@@ -68,6 +77,7 @@ func newStubCliConnection() *stubCliConnection {
 	return &stubCliConnection{
 		cliCommandWithoutTerminalOutputResponse: make(map[string]string),
 		setEnvErrors:                            make(map[string]error),
+		bindServiceErrors:                       make(map[string]error),
 	}
 }
 
@@ -83,6 +93,8 @@ func (s *stubCliConnection) GetApp(name string) (plugin_models.GetAppModel, erro
 		Name:            s.getAppName,
 		Guid:            s.getAppGuid,
 		EnvironmentVars: s.getAppEnvVars,
+		State:           s.getAppState,
+		Routes:          s.getAppRoutes,
 	}, s.getAppError
 }
 
@@ -103,6 +115,15 @@ func (s *stubCliConnection) GetCurrentOrg() (plugin_models.Organization, error)
 	}, s.currentOrgError
 }
 
+func (s *stubCliConnection) GetOrg(name string) (plugin_models.GetOrg_Model, error) {
+	s.getOrgName = name
+
+	return plugin_models.GetOrg_Model{
+		Name: name,
+		Guid: s.getOrgGuid,
+	}, s.getOrgError
+}
+
 func (s *stubCliConnection) GetServices() ([]plugin_models.GetServices_Model, error) {
 	resp := []plugin_models.GetServices_Model{
 		{
@@ -158,8 +179,13 @@ func (s *stubCliConnection) CliCommand(args ...string) ([]string, error) {
 		err = s.createUserError
 	case "create-user-provided-service":
 		err = s.createServiceError
+	case "update-user-provided-service":
+		err = s.updateServiceError
 	case "bind-service":
 		err = s.bindServiceError
+		if appErr, ok := s.bindServiceErrors[args[1]]; ok {
+			err = appErr
+		}
 	case "unbind-service":
 		err = s.unbindServiceError
 	case "delete-service":
@@ -168,6 +194,8 @@ func (s *stubCliConnection) CliCommand(args ...string) ([]string, error) {
 		err = s.pushAppError
 	case "start":
 		err = s.startAppError
+	case "restart":
+		err = s.restartAppError
 	case "delete":
 		err = s.deleteAppError
 	}
@@ -204,15 +232,42 @@ func (l *stubLogger) Print(a ...interface{}) {
 }
 
 type stubDownloader struct {
-	path      string
-	assetName string
+	path         string
+	assetName    string
+	version      string
+	preRelease   bool
+	skipChecksum bool
 }
 
 func newStubDownloader() *stubDownloader {
 	return &stubDownloader{}
 }
 
-func (s *stubDownloader) Download(assetName string) string {
+func (s *stubDownloader) Download(assetName, version string, preRelease, skipChecksum bool) string {
 	s.assetName = assetName
+	s.version = version
+	s.preRelease = preRelease
+	s.skipChecksum = skipChecksum
 	return s.path
 }
+
+type stubEnvFetcher struct {
+	envs map[string]map[string]string
+	errs map[string]error
+	err  error
+}
+
+func newStubEnvFetcher() *stubEnvFetcher {
+	return &stubEnvFetcher{
+		envs: make(map[string]map[string]string),
+		errs: make(map[string]error),
+	}
+}
+
+func (s *stubEnvFetcher) EnvVars(appGuid string) (map[string]string, error) {
+	if err, ok := s.errs[appGuid]; ok {
+		return nil, err
+	}
+
+	return s.envs[appGuid], s.err
+}
@@ -57,22 +57,28 @@ func CreateDrain(
 	opts.AppName = args[0]
 	opts.DrainURL = args[1]
 
-	u, err := url.Parse(opts.DrainURL)
+	u := parseDrainURL(opts.DrainURL, opts.DrainType, log)
+
+	createAndBindService(cli, u, opts.AppName, opts.drainName(), log)
+}
+
+// parseDrainURL parses a syslog drain URL and sets its drain-type query
+// parameter if a type is given.
+func parseDrainURL(drainURL, drainType string, log Logger) *url.URL {
+	u, err := url.Parse(drainURL)
 	if err != nil {
 		log.Fatalf("Invalid syslog drain URL: %s", err)
 	}
 
-	if opts.DrainType != "" {
-		if !validDrainType(opts.DrainType) {
-			log.Fatalf("Invalid type: %s", opts.DrainType)
+	if drainType != "" {
+		if !validDrainType(drainType) {
+			log.Fatalf("Invalid type: %s", drainType)
 		}
 
-		qValues := u.Query()
-		qValues.Set("drain-type", opts.DrainType)
-		u.RawQuery = qValues.Encode()
+		setDrainType(u, drainType)
 	}
 
-	createAndBindService(cli, u, opts.AppName, opts.drainName(), log)
+	return u
 }
 
 func createAndBindService(
@@ -113,6 +119,14 @@ func sourceID(cli plugin.CliConnection, appName string) (string, error) {
 	return app.Guid, nil
 }
 
+// setDrainType sets the drain-type query parameter that tells the syslog
+// agents which envelopes to send to the drain.
+func setDrainType(u *url.URL, drainType string) {
+	qValues := u.Query()
+	qValues.Set("drain-type", drainType)
+	u.RawQuery = qValues.Encode()
+}
+
 func validDrainType(drainType string) bool {
 	switch drainType {
 	case "logs", "metrics", "all":
@@ -0,0 +1,38 @@
+package command
+
+import (
+	"code.cloudfoundry.org/cli/plugin"
+	flags "github.com/jessevdk/go-flags"
+)
+
+type createUnboundDrainOpts struct {
+	DrainType string `long:"type"`
+}
+
+// CreateUnboundDrain creates the user provided service for a syslog drain
+// without binding it to any app.
+func CreateUnboundDrain(
+	cli plugin.CliConnection,
+	args []string,
+	log Logger,
+) {
+	opts := createUnboundDrainOpts{}
+
+	parser := flags.NewParser(&opts, flags.HelpFlag|flags.PassDoubleDash)
+	args, err := parser.ParseArgs(args)
+	if err != nil {
+		log.Fatalf("%s", err)
+	}
+
+	if len(args) != 2 {
+		log.Fatalf("Invalid arguments, expected 2, got %d.", len(args))
+	}
+
+	drainName := args[0]
+	u := parseDrainURL(args[1], opts.DrainType, log)
+
+	_, err = cli.CliCommand("create-user-provided-service", drainName, "-l", u.String())
+	if err != nil {
+		log.Fatalf("%s", err)
+	}
+}
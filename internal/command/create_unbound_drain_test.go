@@ -0,0 +1,69 @@
+package command_test
+
+import (
+	"errors"
+
+	"code.cloudfoundry.org/cf-drain-cli/internal/command"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CreateUnboundDrain", func() {
+	var (
+		logger *stubLogger
+		cli    *stubCliConnection
+	)
+
+	BeforeEach(func() {
+		logger = &stubLogger{}
+		cli = newStubCliConnection()
+	})
+
+	It("creates a drain without binding it", func() {
+		args := []string{"my-drain", "syslog://a.com?a=b"}
+
+		command.CreateUnboundDrain(cli, args, logger)
+
+		Expect(cli.cliCommandArgs).To(Equal([][]string{
+			{"create-user-provided-service", "my-drain", "-l", "syslog://a.com?a=b"},
+		}))
+	})
+
+	It("adds the drain type to the URL", func() {
+		args := []string{"my-drain", "syslog://a.com?a=b", "--type", "metrics"}
+
+		command.CreateUnboundDrain(cli, args, logger)
+
+		Expect(cli.cliCommandArgs).To(Equal([][]string{
+			{"create-user-provided-service", "my-drain", "-l", "syslog://a.com?a=b&drain-type=metrics"},
+		}))
+	})
+
+	It("fatally logs for an invalid type", func() {
+		args := []string{"my-drain", "syslog://a.com", "--type", "bogus"}
+
+		Expect(func() {
+			command.CreateUnboundDrain(cli, args, logger)
+		}).To(Panic())
+		Expect(logger.fatalfMessage).To(Equal("Invalid type: bogus"))
+		Expect(cli.cliCommandArgs).To(BeEmpty())
+	})
+
+	It("fatally logs if creating the service fails", func() {
+		cli.createServiceError = errors.New("unable to create")
+		args := []string{"my-drain", "syslog://a.com"}
+
+		Expect(func() {
+			command.CreateUnboundDrain(cli, args, logger)
+		}).To(Panic())
+		Expect(logger.fatalfMessage).To(Equal("unable to create"))
+	})
+
+	It("expects to receive 2 arguments", func() {
+		Expect(func() {
+			command.CreateUnboundDrain(cli, []string{"my-drain"}, logger)
+		}).To(Panic())
+		Expect(logger.fatalfMessage).To(Equal("Invalid arguments, expected 2, got 1."))
+	})
+})
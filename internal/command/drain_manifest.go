@@ -0,0 +1,174 @@
+package command
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"code.cloudfoundry.org/cf-drain-cli/internal/drain"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// drainManifest describes the drains of a space. It is read by apply-drains
+// and written by export-drains.
+type drainManifest struct {
+	Drains []manifestDrain `yaml:"drains" json:"drains"`
+}
+
+type manifestDrain struct {
+	Name      string   `yaml:"name" json:"name"`
+	URL       string   `yaml:"url" json:"url"`
+	Type      string   `yaml:"type,omitempty" json:"type,omitempty"`
+	Apps      []string `yaml:"apps,omitempty" json:"apps,omitempty"`
+	Selectors []string `yaml:"selectors,omitempty" json:"selectors,omitempty"`
+}
+
+// drainURL returns the syslog drain URL of the drain with its type added as
+// the drain-type query parameter.
+func (d manifestDrain) drainURL() (string, error) {
+	u, err := url.Parse(d.URL)
+	if err != nil {
+		return "", err
+	}
+
+	if d.Type != "" {
+		setDrainType(u, d.Type)
+	}
+
+	return u.String(), nil
+}
+
+// placeholderPattern matches the ((name)) placeholders written by
+// export-drains.
+var placeholderPattern = regexp.MustCompile(`\(\(([^()]+)\)\)`)
+
+// readDrainManifest reads and validates a YAML or JSON drain manifest. The
+// placeholders in drain URLs are replaced by the given vars and a drain URL
+// with a placeholder that has no value is rejected.
+func readDrainManifest(path string, vars map[string]string) (drainManifest, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return drainManifest{}, err
+	}
+
+	var m drainManifest
+	err = yaml.UnmarshalStrict(data, &m)
+	if err != nil {
+		return drainManifest{}, err
+	}
+
+	names := make(map[string]bool)
+	for i, d := range m.Drains {
+		if d.Name == "" {
+			return drainManifest{}, fmt.Errorf("drain %d has no name", i+1)
+		}
+
+		if names[d.Name] {
+			return drainManifest{}, fmt.Errorf("drain %s is listed more than once", d.Name)
+		}
+		names[d.Name] = true
+
+		if d.URL == "" {
+			return drainManifest{}, fmt.Errorf("drain %s has no url", d.Name)
+		}
+
+		d.URL = interpolate(d.URL, vars)
+		if missing := placeholderPattern.FindAllString(d.URL, -1); len(missing) > 0 {
+			return drainManifest{}, fmt.Errorf(
+				"drain %s has unresolved placeholders: %s",
+				d.Name,
+				strings.Join(missing, ", "),
+			)
+		}
+		m.Drains[i].URL = d.URL
+
+		if _, err := url.Parse(d.URL); err != nil {
+			return drainManifest{}, fmt.Errorf("drain %s has an invalid url: %s", d.Name, err)
+		}
+
+		if d.Type != "" && !validDrainType(d.Type) {
+			return drainManifest{}, fmt.Errorf("drain %s has an invalid type: %s", d.Name, d.Type)
+		}
+	}
+
+	return m, nil
+}
+
+// interpolate replaces the placeholders in a drain URL that have a value in
+// vars. Values are escaped so that they can be used in the user info and in
+// query parameters.
+func interpolate(drainURL string, vars map[string]string) string {
+	return placeholderPattern.ReplaceAllStringFunc(drainURL, func(p string) string {
+		v, ok := vars[placeholderPattern.FindStringSubmatch(p)[1]]
+		if !ok {
+			return p
+		}
+
+		return strings.Replace(url.QueryEscape(v), "+", "%20", -1)
+	})
+}
+
+// readVars reads the vars used to fill in manifest placeholders from an
+// optional YAML vars file and NAME=VALUE pairs. The pairs take precedence
+// over the vars file.
+func readVars(varsFile string, pairs []string) (map[string]string, error) {
+	vars := make(map[string]string)
+	if varsFile != "" {
+		data, err := ioutil.ReadFile(varsFile)
+		if err != nil {
+			return nil, err
+		}
+
+		err = yaml.Unmarshal(data, &vars)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, p := range pairs {
+		kv := strings.SplitN(p, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("invalid var %s, expected NAME=VALUE", p)
+		}
+		vars[kv[0]] = kv[1]
+	}
+
+	return vars, nil
+}
+
+// newManifestDrain converts a drain into its manifest representation. The
+// drain-type query parameter becomes the drain's type and its apps are
+// sorted so that exports are reproducible. Unless includeSecrets is set the
+// credentials in the drain URL are replaced by placeholders.
+func newManifestDrain(d drain.Drain, includeSecrets bool) (manifestDrain, error) {
+	u, err := url.Parse(d.DrainURL)
+	if err != nil {
+		return manifestDrain{}, err
+	}
+
+	md := manifestDrain{
+		Name: d.Name,
+	}
+
+	query := u.Query()
+	if drainType, ok := query["drain-type"]; ok {
+		md.Type = drainType[0]
+		delete(query, "drain-type")
+		u.RawQuery = query.Encode()
+	}
+
+	md.URL = u.String()
+	if !includeSecrets {
+		md.URL = drain.RedactURL(u, func(part string) string {
+			return fmt.Sprintf("((%s-%s))", d.Name, part)
+		})
+	}
+
+	md.Apps = append([]string(nil), d.Apps...)
+	sort.Strings(md.Apps)
+
+	return md, nil
+}
@@ -0,0 +1,166 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"text/tabwriter"
+
+	"code.cloudfoundry.org/cf-drain-cli/internal/drain"
+	"code.cloudfoundry.org/cli/plugin"
+	"code.cloudfoundry.org/cli/plugin/models"
+)
+
+type EnvFetcher interface {
+	EnvVars(appGUID string) (map[string]string, error)
+}
+
+type HTTPGetter interface {
+	Get(url string) (*http.Response, error)
+}
+
+// DrainSpaceStatus reports the state of a space drain app and which apps in
+// its space are covered by the drain, still pending, or excluded.
+func DrainSpaceStatus(
+	cli plugin.CliConnection,
+	args []string,
+	log Logger,
+	w io.Writer,
+	df DrainFetcher,
+	al AppLister,
+	ef EnvFetcher,
+	hc HTTPGetter,
+) {
+	if len(args) != 1 {
+		log.Fatalf("Invalid arguments, expected 1, got %d.", len(args))
+	}
+
+	appName := args[0]
+
+	app, err := cli.GetApp(appName)
+	if err != nil {
+		log.Fatalf("%s", err)
+	}
+
+	envs := stringEnvs(app.EnvironmentVars)
+	if envs["DRAIN_SCOPE"] != "space" {
+		log.Fatalf("%s is not a space drain.", appName)
+	}
+
+	drainName := envs["DRAIN_NAME"]
+	spaceGuid := envs["SPACE_ID"]
+
+	drains, err := df.Drains(spaceGuid)
+	if err != nil {
+		log.Fatalf("Failed to fetch drains: %s", err)
+	}
+	d, _ := findDrain(drains, drainName)
+
+	apps, err := al.ListAppsWithSelector(spaceGuid, envs["DRAIN_SELECTOR"])
+	if err != nil {
+		log.Fatalf("Failed to list apps: %s", err)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 2, 2, ' ', 0)
+	fmt.Fprintf(tw, "space drain:\t%s\n", appName)
+	fmt.Fprintf(tw, "state:\t%s\n", app.State)
+	fmt.Fprintf(tw, "version:\t%s\n", spaceDrainVersion(hc, app))
+	fmt.Fprintf(tw, "drain:\t%s\n", drainName)
+	fmt.Fprintf(tw, "url:\t%s\n", sanitizeDrainURL(envs["DRAIN_URL"]))
+	fmt.Fprintf(tw, "type:\t%s\n", envs["DRAIN_TYPE"])
+	if envs["DRAIN_SELECTOR"] != "" {
+		fmt.Fprintf(tw, "selector:\t%s\n", envs["DRAIN_SELECTOR"])
+	}
+	fmt.Fprintln(tw)
+
+	fmt.Fprintln(tw, "app\tstatus")
+	var covered, pending, excluded, unknown int
+	for _, a := range apps {
+		if a.Guid == app.Guid {
+			continue
+		}
+
+		// Like the space drain, an app whose env can not be read is
+		// reported as unknown instead of failing the whole report.
+		appEnvs, err := ef.EnvVars(a.Guid)
+
+		var status string
+		switch {
+		case err != nil:
+			status = fmt.Sprintf("unknown (failed to read env variables: %s)", err)
+			unknown++
+		case appEnvs["DRAIN_SCOPE"] == "space":
+			status = "excluded (space drain)"
+			excluded++
+		case drain.IsExcluded(a.Annotations, appEnvs, drainName):
+			status = "excluded"
+			excluded++
+		case containsString(d.AppGuids, a.Guid):
+			status = "covered"
+			covered++
+		default:
+			status = "pending"
+			pending++
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\n", a.Name, status)
+	}
+	fmt.Fprintln(tw)
+	fmt.Fprintf(tw, "%d covered, %d pending, %d excluded", covered, pending, excluded)
+	if unknown != 0 {
+		fmt.Fprintf(tw, ", %d unknown", unknown)
+	}
+	fmt.Fprintln(tw)
+
+	err = tw.Flush()
+	if err != nil {
+		log.Fatalf("Failed to write status: %s", err)
+	}
+}
+
+// spaceDrainVersion fetches the version the space drain app reports on its
+// /version endpoint. It returns unknown if the app has no route or does not
+// answer.
+func spaceDrainVersion(hc HTTPGetter, app plugin_models.GetAppModel) string {
+	if len(app.Routes) == 0 {
+		return "unknown"
+	}
+
+	r := app.Routes[0]
+	host := r.Domain.Name
+	if r.Host != "" {
+		host = r.Host + "." + host
+	}
+
+	resp, err := hc.Get(fmt.Sprintf("https://%s%s/version", host, r.Path))
+	if err != nil {
+		return "unknown"
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return "unknown"
+	}
+
+	var v struct {
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(body, &v); err != nil || v.Version == "" {
+		return "unknown"
+	}
+
+	return v.Version
+}
+
+func stringEnvs(envs map[string]interface{}) map[string]string {
+	s := make(map[string]string, len(envs))
+	for k, v := range envs {
+		s[k] = strings.TrimSpace(fmt.Sprint(v))
+	}
+
+	return s
+}
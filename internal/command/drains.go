@@ -1,50 +1,180 @@
 package command
 
 import (
+	"encoding/csv"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/url"
 	"strings"
 	"text/tabwriter"
 
+	"code.cloudfoundry.org/cf-drain-cli/internal/cloudcontroller"
 	"code.cloudfoundry.org/cf-drain-cli/internal/drain"
 	"code.cloudfoundry.org/cli/plugin"
+	flags "github.com/jessevdk/go-flags"
 )
 
 type DrainFetcher interface {
 	Drains(spaceGUID string) ([]drain.Drain, error)
 }
 
+type SpaceLister interface {
+	ListSpaces(orgGUID string) ([]cloudcontroller.Space, error)
+}
+
+type drainsOpts struct {
+	Output    string `long:"output" short:"o"`
+	Org       string `long:"org"`
+	AllSpaces bool   `long:"all-spaces"`
+}
+
+// drainRecord is the machine readable representation of a drain. Its field
+// names are part of the JSON and CSV output and should not change.
+type drainRecord struct {
+	Name     string   `json:"name"`
+	Guid     string   `json:"guid"`
+	Type     string   `json:"type"`
+	URL      string   `json:"url"`
+	Apps     []string `json:"apps"`
+	AppGuids []string `json:"app_guids"`
+	Org      string   `json:"org"`
+	Space    string   `json:"space"`
+}
+
+// spaceDrain is a drain along with the org and space it was found in.
+type spaceDrain struct {
+	drain.Drain
+
+	Org   string
+	Space string
+}
+
 func Drains(
 	cli plugin.CliConnection,
 	args []string,
 	log Logger,
 	tableWriter io.Writer,
+	sl SpaceLister,
 	fetchers ...DrainFetcher,
 ) {
+	opts := drainsOpts{
+		Output: "table",
+	}
+
+	parser := flags.NewParser(&opts, flags.HelpFlag|flags.PassDoubleDash)
+	args, err := parser.ParseArgs(args)
+	if err != nil {
+		log.Fatalf("%s", err)
+	}
+
 	if len(args) != 0 {
 		log.Fatalf("Invalid arguments, expected 0, got %d.", len(args))
 	}
 
-	space, err := cli.GetCurrentSpace()
+	if !validOutputFormat(opts.Output) {
+		log.Fatalf("Invalid output format: %s", opts.Output)
+	}
+
+	if opts.Org != "" && opts.AllSpaces {
+		log.Fatalf("Only one of --org and --all-spaces may be given.")
+	}
+
+	spaces := drainsSpaces(cli, sl, opts, log)
+
+	var drains []spaceDrain
+	for _, space := range spaces {
+		for _, f := range fetchers {
+			d, err := f.Drains(space.Guid)
+			if err != nil {
+				log.Fatalf("Failed to fetch drains: %s", err)
+			}
+
+			for _, dd := range d {
+				drains = append(drains, spaceDrain{
+					Drain: dd,
+					Org:   space.OrgName,
+					Space: space.Name,
+				})
+			}
+		}
+	}
+
+	switch opts.Output {
+	case "json":
+		err = writeDrainsJSON(tableWriter, drains)
+	case "csv":
+		err = writeDrainsCSV(tableWriter, drains)
+	default:
+		multiSpace := opts.Org != "" || opts.AllSpaces
+		err = writeDrainsTable(tableWriter, drains, multiSpace)
+	}
 	if err != nil {
-		log.Fatalf("%s", err)
+		log.Fatalf("Failed to write drains: %s", err)
 	}
+}
 
-	var drains []drain.Drain
+// drainsSpaces returns the spaces that drains should be listed for. Without
+// --org or --all-spaces this is the currently targeted space.
+func drainsSpaces(cli plugin.CliConnection, sl SpaceLister, opts drainsOpts, log Logger) []cloudcontroller.Space {
+	switch {
+	case opts.AllSpaces:
+		spaces, err := sl.ListSpaces("")
+		if err != nil {
+			log.Fatalf("Failed to fetch spaces: %s", err)
+		}
+		return spaces
+	case opts.Org != "":
+		org, err := cli.GetOrg(opts.Org)
+		if err != nil {
+			log.Fatalf("%s", err)
+		}
 
-	for _, f := range fetchers {
-		d, err := f.Drains(space.Guid)
+		spaces, err := sl.ListSpaces(org.Guid)
 		if err != nil {
-			log.Fatalf("Failed to fetch drains: %s", err)
+			log.Fatalf("Failed to fetch spaces: %s", err)
 		}
-		drains = append(drains, d...)
+		return spaces
+	default:
+		space, err := cli.GetCurrentSpace()
+		if err != nil {
+			log.Fatalf("%s", err)
+		}
+
+		org, err := cli.GetCurrentOrg()
+		if err != nil {
+			log.Fatalf("%s", err)
+		}
+
+		return []cloudcontroller.Space{{
+			Name:    space.Name,
+			Guid:    space.Guid,
+			OrgName: org.Name,
+			OrgGuid: org.Guid,
+		}}
 	}
+}
+
+func validOutputFormat(format string) bool {
+	switch format {
+	case "table", "json", "csv":
+		return true
+	default:
+		return false
+	}
+}
 
-	tw := tabwriter.NewWriter(tableWriter, 10, 2, 2, ' ', 0)
+// writeDrainsTable writes one row per app and drain. When drains come from
+// more than one space the org and space are included as well.
+func writeDrainsTable(w io.Writer, drains []spaceDrain, multiSpace bool) error {
+	tw := tabwriter.NewWriter(w, 10, 2, 2, ' ', 0)
 
 	// Header
-	fmt.Fprintln(tw, "App\tDrain\tType\tURL")
+	header := []string{"App", "Drain", "Type", "URL"}
+	if multiSpace {
+		header = append([]string{"Org", "Space"}, header...)
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
 	for _, d := range drains {
 		for _, app := range d.Apps {
 			drain := []string{
@@ -53,32 +183,83 @@ func Drains(
 				strings.Title(d.Type),
 				sanitizeDrainURL(d.DrainURL),
 			}
+			if multiSpace {
+				drain = append([]string{d.Org, d.Space}, drain...)
+			}
 			fmt.Fprintln(tw, strings.Join(drain, "\t"))
 		}
 	}
 
-	tw.Flush()
+	return tw.Flush()
+}
+
+func writeDrainsJSON(w io.Writer, drains []spaceDrain) error {
+	records := make([]drainRecord, 0, len(drains))
+	for _, d := range drains {
+		records = append(records, newDrainRecord(d))
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(records)
 }
 
+// writeDrainsCSV writes one row per drain. App names and GUIDs are joined
+// with semicolons so that each drain stays on a single row.
+func writeDrainsCSV(w io.Writer, drains []spaceDrain) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"name", "guid", "type", "url", "apps", "app_guids", "org", "space"})
+	for _, d := range drains {
+		r := newDrainRecord(d)
+		cw.Write([]string{
+			r.Name,
+			r.Guid,
+			r.Type,
+			r.URL,
+			strings.Join(r.Apps, ";"),
+			strings.Join(r.AppGuids, ";"),
+			r.Org,
+			r.Space,
+		})
+	}
+	cw.Flush()
+
+	return cw.Error()
+}
+
+func newDrainRecord(d spaceDrain) drainRecord {
+	r := drainRecord{
+		Name:     d.Name,
+		Guid:     d.Guid,
+		Type:     d.Type,
+		URL:      sanitizeDrainURL(d.DrainURL),
+		Apps:     d.Apps,
+		AppGuids: d.AppGuids,
+		Org:      d.Org,
+		Space:    d.Space,
+	}
+
+	if r.Apps == nil {
+		r.Apps = []string{}
+	}
+	if r.AppGuids == nil {
+		r.AppGuids = []string{}
+	}
+
+	return r
+}
+
+// sanitizeDrainURL redacts the credentials in a drain URL and drops its
+// drain-type, which is shown separately.
 func sanitizeDrainURL(drainURL string) string {
 	u, err := url.Parse(drainURL)
 	if err != nil {
 		return "failed to parse drain URL"
 	}
 
-	if u.User != nil {
-		u.User = url.UserPassword("---REDACTED---", "---REDACTED---")
-	}
-
 	query := u.Query()
 	delete(query, "drain-type")
-
-	for k, v := range query {
-		for i := range v {
-			query[k][i] = "---REDACTED---"
-		}
-	}
 	u.RawQuery = query.Encode()
 
-	return strings.Replace(u.String(), "---REDACTED---", "<redacted>", -1)
+	return drain.RedactURL(u, nil)
 }
@@ -0,0 +1,141 @@
+package command
+
+import (
+	"net/url"
+	"path"
+	"strings"
+
+	"code.cloudfoundry.org/cf-drain-cli/internal/drain"
+	"code.cloudfoundry.org/cli/plugin"
+)
+
+// DryRunConnection wraps a plugin.CliConnection and prints the CF commands
+// that would change anything instead of running them. Commands that only
+// read state are passed through so that the printed commands match what
+// would really run.
+type DryRunConnection struct {
+	plugin.CliConnection
+
+	log Logger
+}
+
+func NewDryRunConnection(cli plugin.CliConnection, log Logger) *DryRunConnection {
+	return &DryRunConnection{
+		CliConnection: cli,
+		log:           log,
+	}
+}
+
+func (c *DryRunConnection) CliCommand(args ...string) ([]string, error) {
+	if readOnlyCommand(args) {
+		return c.CliConnection.CliCommand(args...)
+	}
+
+	c.printCommand(args)
+	return nil, nil
+}
+
+func (c *DryRunConnection) CliCommandWithoutTerminalOutput(args ...string) ([]string, error) {
+	if readOnlyCommand(args) {
+		return c.CliConnection.CliCommandWithoutTerminalOutput(args...)
+	}
+
+	c.printCommand(args)
+	return nil, nil
+}
+
+func (c *DryRunConnection) printCommand(args []string) {
+	c.log.Printf("[dry-run] cf %s", strings.Join(redactCommandArgs(args), " "))
+}
+
+// DryRunDownloader does not download anything. It returns a placeholder path
+// for the asset so that the commands using it can be printed.
+type DryRunDownloader struct {
+	log Logger
+}
+
+func NewDryRunDownloader(log Logger) DryRunDownloader {
+	return DryRunDownloader{
+		log: log,
+	}
+}
+
+func (d DryRunDownloader) Download(assetName, version string, preRelease, skipChecksum bool) string {
+	if version != "" {
+		d.log.Printf("[dry-run] download %s from release %s", assetName, version)
+		return path.Join("<"+version+">", assetName)
+	}
+
+	if preRelease {
+		d.log.Printf("[dry-run] download %s from the latest release or pre-release", assetName)
+		return path.Join("<latest-release>", assetName)
+	}
+
+	d.log.Printf("[dry-run] download %s from the latest release", assetName)
+	return path.Join("<latest-release>", assetName)
+}
+
+// readOnlyCommand reports whether the CF command only reads state. Any
+// command that is not known to be read only is treated as a change.
+func readOnlyCommand(args []string) bool {
+	if len(args) == 0 {
+		return true
+	}
+
+	switch args[0] {
+	case "app", "apps", "env", "service", "services", "target", "curl":
+	default:
+		return false
+	}
+
+	if args[0] != "curl" {
+		return true
+	}
+
+	for i, a := range args {
+		switch a {
+		case "-d":
+			return false
+		case "-X":
+			if i+1 < len(args) && !strings.EqualFold(args[i+1], "GET") {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// redactCommandArgs replaces credentials in drain URLs and the values of
+// secret environment variables.
+func redactCommandArgs(args []string) []string {
+	redacted := make([]string, len(args))
+	for i, a := range args {
+		switch {
+		case args[0] == "set-env" && i == 3 && secretEnv(args[2]):
+			redacted[i] = "<redacted>"
+		case strings.Contains(a, "://"):
+			u, err := url.Parse(a)
+			if err != nil {
+				redacted[i] = "failed to parse drain URL"
+				continue
+			}
+			redacted[i] = drain.RedactURL(u, nil)
+		default:
+			redacted[i] = a
+		}
+	}
+
+	return redacted
+}
+
+func secretEnv(name string) bool {
+	name = strings.ToUpper(name)
+	for _, s := range []string{"TOKEN", "SECRET", "PASSWORD"} {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+
+	return false
+}
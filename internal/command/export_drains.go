@@ -0,0 +1,82 @@
+package command
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+
+	"code.cloudfoundry.org/cli/plugin"
+	flags "github.com/jessevdk/go-flags"
+	yaml "gopkg.in/yaml.v2"
+)
+
+type exportDrainsOpts struct {
+	Output         string `long:"output" short:"o"`
+	IncludeSecrets bool   `long:"include-secrets"`
+}
+
+// ExportDrains writes the drains in the current space as a manifest that can
+// be given to apply-drains.
+func ExportDrains(
+	cli plugin.CliConnection,
+	args []string,
+	log Logger,
+	w io.Writer,
+	df DrainFetcher,
+) {
+	opts := exportDrainsOpts{
+		Output: "yaml",
+	}
+
+	parser := flags.NewParser(&opts, flags.HelpFlag|flags.PassDoubleDash)
+	args, err := parser.ParseArgs(args)
+	if err != nil {
+		log.Fatalf("%s", err)
+	}
+
+	if len(args) != 0 {
+		log.Fatalf("Invalid arguments, expected 0, got %d.", len(args))
+	}
+
+	if opts.Output != "yaml" && opts.Output != "json" {
+		log.Fatalf("Invalid output format: %s", opts.Output)
+	}
+
+	space := currentSpace(cli, log)
+
+	drains, err := df.Drains(space.Guid)
+	if err != nil {
+		log.Fatalf("Failed to fetch drains: %s", err)
+	}
+
+	m := drainManifest{
+		Drains: []manifestDrain{},
+	}
+	for _, d := range drains {
+		md, err := newManifestDrain(d, opts.IncludeSecrets)
+		if err != nil {
+			log.Fatalf("Failed to parse URL of drain %s: %s", d.Name, err)
+		}
+		m.Drains = append(m.Drains, md)
+	}
+	sort.Slice(m.Drains, func(i, j int) bool {
+		return m.Drains[i].Name < m.Drains[j].Name
+	})
+
+	var data []byte
+	switch opts.Output {
+	case "json":
+		data, err = json.MarshalIndent(m, "", "  ")
+		data = append(data, '\n')
+	default:
+		data, err = yaml.Marshal(m)
+	}
+	if err != nil {
+		log.Fatalf("Failed to encode drains: %s", err)
+	}
+
+	_, err = w.Write(data)
+	if err != nil {
+		log.Fatalf("Failed to write drains: %s", err)
+	}
+}
@@ -1,14 +1,18 @@
 package command
 
 import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"os"
 	"path"
+	"path/filepath"
 	"sort"
-	"strconv"
 	"strings"
 )
 
@@ -17,33 +21,152 @@ type HTTPClient interface {
 }
 
 type GithubReleaseDownloader struct {
-	log Logger
-	c   HTTPClient
+	log         Logger
+	c           HTTPClient
+	releasesURL string
+	token       string
+	githubToken string
+	cacheDir    string
 }
 
-func NewGithubReleaseDownloader(c HTTPClient, log Logger) GithubReleaseDownloader {
-	return GithubReleaseDownloader{
-		log: log,
-		c:   c,
+func NewGithubReleaseDownloader(c HTTPClient, log Logger, opts ...GithubReleaseDownloaderOption) GithubReleaseDownloader {
+	d := GithubReleaseDownloader{
+		log:         log,
+		c:           c,
+		releasesURL: defaultReleasesURL,
+	}
+
+	for _, o := range opts {
+		o(&d)
+	}
+
+	return d
+}
+
+type GithubReleaseDownloaderOption func(d *GithubReleaseDownloader)
+
+// WithReleasesURL sets the GitHub API URL releases are listed from, e.g. the
+// releases of a mirror on GitHub Enterprise. An empty URL keeps the default.
+func WithReleasesURL(u string) GithubReleaseDownloaderOption {
+	return func(d *GithubReleaseDownloader) {
+		if u != "" {
+			d.releasesURL = u
+		}
+	}
+}
+
+// WithGithubToken sets a token sent to the host of the releases URL. It
+// raises GitHub's rate limits and gives access to private mirrors.
+func WithGithubToken(token string) GithubReleaseDownloaderOption {
+	return func(d *GithubReleaseDownloader) {
+		d.token = token
+	}
+}
+
+// WithGithubComToken sets a token that is only sent when releases are listed
+// from api.github.com. It is meant for ambient tokens such as GITHUB_TOKEN,
+// which must not leak to a mirror. A token given with WithGithubToken takes
+// precedence.
+func WithGithubComToken(token string) GithubReleaseDownloaderOption {
+	return func(d *GithubReleaseDownloader) {
+		d.githubToken = token
+	}
+}
+
+// WithCacheDir keeps verified assets in dir so that a release is only
+// downloaded once. Without it assets are downloaded to a temp directory
+// every time.
+func WithCacheDir(dir string) GithubReleaseDownloaderOption {
+	return func(d *GithubReleaseDownloader) {
+		d.cacheDir = dir
 	}
 }
 
-func (d GithubReleaseDownloader) Download(assetName string) string {
-	releases := d.getReleases()
+// Download fetches the asset from the release with the given tag, or from
+// the latest release that has it when version is empty. Drafts are never
+// used and pre-releases are only picked as the latest release when
+// preRelease is set. The asset is verified against the SHA-256 checksum
+// published with the release unless skipChecksum is set. Only verified
+// assets are cached, and a cached copy of a pinned version is used without
+// contacting GitHub. A pinned version is looked up by its tag, so it is
+// found no matter how many releases came after it.
+func (d GithubReleaseDownloader) Download(assetName, version string, preRelease, skipChecksum bool) string {
+	if version != "" {
+		if p, ok := d.cachedAsset(assetName, version); ok {
+			return p
+		}
+	}
+
+	var releases githubReleases
+	if version != "" {
+		releases = githubReleases{d.getRelease(version)}
+	} else {
+		releases = d.getReleases()
+	}
 
-	sort.Sort(githubReleases(releases))
+	sort.Stable(releases)
 	for _, release := range releases {
-		for _, asset := range release.Assets {
-			if asset.Name == assetName {
-				tmp, err := ioutil.TempDir("", asset.Name)
-				if err != nil {
-					d.log.Fatalf("failed to create temp directory: %s", err)
-				}
-				p := path.Join(tmp, asset.Name)
-				d.downloadAsset(asset.Name, asset.BrowserDownloadURL, p)
-				return p
+		if release.Draft {
+			continue
+		}
+
+		if version != "" && release.TagName != version {
+			continue
+		}
+
+		if version == "" && !preRelease && release.isPreRelease() {
+			continue
+		}
+
+		assetURL, ok := release.assetURL(assetName)
+		if !ok {
+			if version != "" {
+				d.log.Fatalf("unable to find %s asset in release %s", assetName, version)
 			}
+			continue
+		}
+
+		if p, ok := d.cachedAsset(assetName, release.TagName); ok {
+			return p
 		}
+
+		var checksum string
+		if skipChecksum {
+			d.log.Printf("WARNING: not verifying the checksum of %s %s, --skip-checksum was given.", assetName, release.TagName)
+		} else {
+			checksumsURL, ok := release.assetURL(checksumsAssetName)
+			if !ok {
+				d.log.Fatalf(
+					"release %s has no %s to verify %s, use --skip-checksum to use it without verifying it",
+					release.TagName,
+					checksumsAssetName,
+					assetName,
+				)
+			}
+			checksum = d.checksum(assetName, release.TagName, checksumsURL)
+		}
+
+		tmp, err := ioutil.TempDir("", assetName)
+		if err != nil {
+			d.log.Fatalf("failed to create temp directory: %s", err)
+		}
+		p := path.Join(tmp, assetName)
+		d.downloadAsset(assetName, assetURL, p, checksum)
+
+		// An unverified asset must not be trusted by later runs.
+		if skipChecksum {
+			return p
+		}
+
+		if cached, ok := d.cacheAsset(assetName, release.TagName, p, checksum); ok {
+			os.RemoveAll(tmp)
+			return cached
+		}
+		return p
+	}
+
+	if version != "" {
+		d.log.Fatalf("unable to find release %s", version)
 	}
 
 	d.log.Fatalf("unable to find %s asset in releases", assetName)
@@ -51,24 +174,22 @@ func (d GithubReleaseDownloader) Download(assetName string) string {
 }
 
 func (d GithubReleaseDownloader) getReleases() githubReleases {
-	req, err := http.NewRequest(http.MethodGet, "https://api.github.com/repos/cloudfoundry/cf-drain-cli/releases", nil)
+	u, err := url.Parse(d.releasesURL)
 	if err != nil {
-		d.log.Fatalf("failed to create request to github: %s", err)
+		d.log.Fatalf("invalid releases URL: %s", err)
 	}
-
-	resp, err := d.c.Do(req)
-	if err != nil {
-		d.log.Fatalf("failed to read from github: %s", err)
+	q := u.Query()
+	if q.Get("per_page") == "" {
+		q.Set("per_page", "100")
+		u.RawQuery = q.Encode()
 	}
+
+	resp := d.get(u.String())
 	defer func() {
 		io.Copy(ioutil.Discard, resp.Body)
 		resp.Body.Close()
 	}()
 
-	if resp.StatusCode != http.StatusOK {
-		d.log.Fatalf("unexpected status code (%d) from github", resp.StatusCode)
-	}
-
 	var releases githubReleases
 	err = json.NewDecoder(resp.Body).Decode(&releases)
 	if err != nil {
@@ -78,25 +199,134 @@ func (d GithubReleaseDownloader) getReleases() githubReleases {
 	return releases
 }
 
-func (d GithubReleaseDownloader) downloadAsset(assetName, URL, p string) {
-	req, err := http.NewRequest(http.MethodGet, URL, nil)
+// getRelease fetches the release with the given tag.
+func (d GithubReleaseDownloader) getRelease(tag string) githubRelease {
+	u, err := url.Parse(d.releasesURL)
 	if err != nil {
-		d.log.Fatalf("failed to create request to github: %s", err)
+		d.log.Fatalf("invalid releases URL: %s", err)
 	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/tags/" + tag
+	u.RawPath = ""
 
-	resp, err := d.c.Do(req)
+	resp := d.do(u.String())
+	defer func() {
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+	}()
+
+	if resp.StatusCode == http.StatusNotFound {
+		d.log.Fatalf("unable to find release %s", tag)
+	}
+	d.checkStatus(resp)
+
+	var release githubRelease
+	err = json.NewDecoder(resp.Body).Decode(&release)
 	if err != nil {
-		d.log.Fatalf("failed to read from github: %s", err)
+		d.log.Fatalf("failed to decode release response from github")
+	}
+
+	return release
+}
+
+// cachedAsset returns the path of the cached asset if it still matches the
+// checksum it was verified against.
+func (d GithubReleaseDownloader) cachedAsset(assetName, tagName string) (string, bool) {
+	if !d.cacheable(tagName) {
+		return "", false
 	}
+
+	dir := filepath.Join(d.cacheDir, tagName)
+	checksum, err := ioutil.ReadFile(filepath.Join(dir, assetName+".sha256"))
+	if err != nil {
+		return "", false
+	}
+
+	p := filepath.Join(dir, assetName, assetName)
+	actual, err := fileChecksum(p)
+	if err != nil {
+		return "", false
+	}
+
+	if actual != strings.TrimSpace(string(checksum)) {
+		d.log.Printf("Cached %s %s does not match its checksum, downloading it again.", assetName, tagName)
+		return "", false
+	}
+
+	return p, true
+}
+
+// cacheAsset moves a verified asset into the cache. The asset gets a
+// directory of its own so that only it is pushed. Failing to cache is not
+// fatal, the downloaded asset is used instead.
+func (d GithubReleaseDownloader) cacheAsset(assetName, tagName, src, checksum string) (string, bool) {
+	if !d.cacheable(tagName) {
+		return "", false
+	}
+
+	dir := filepath.Join(d.cacheDir, tagName)
+	p := filepath.Join(dir, assetName, assetName)
+	err := os.MkdirAll(filepath.Dir(p), 0755)
+	if err == nil {
+		err = copyFile(src, p)
+	}
+	if err == nil {
+		err = ioutil.WriteFile(filepath.Join(dir, assetName+".sha256"), []byte(checksum+"\n"), 0644)
+	}
+	if err != nil {
+		d.log.Printf("Failed to cache %s %s: %s", assetName, tagName, err)
+		return "", false
+	}
+
+	return p, true
+}
+
+func (d GithubReleaseDownloader) cacheable(tagName string) bool {
+	if d.cacheDir == "" || tagName == "" || tagName == "." || tagName == ".." {
+		return false
+	}
+
+	return !strings.ContainsAny(tagName, `/\`)
+}
+
+// checksum reads the expected SHA-256 checksum of the asset from the
+// release's checksums file. Each line holds a hex encoded checksum and an
+// asset name, as written by sha256sum.
+func (d GithubReleaseDownloader) checksum(assetName, tagName, URL string) string {
+	resp := d.get(URL)
 	defer func() {
 		io.Copy(ioutil.Discard, resp.Body)
 		resp.Body.Close()
 	}()
 
-	if resp.StatusCode != http.StatusOK {
-		d.log.Fatalf("unexpected status code (%d) from github", resp.StatusCode)
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+
+		if strings.TrimPrefix(fields[1], "*") == assetName {
+			return strings.ToLower(fields[0])
+		}
 	}
 
+	if err := scanner.Err(); err != nil {
+		d.log.Fatalf("failed to read %s from github: %s", checksumsAssetName, err)
+	}
+
+	d.log.Fatalf("release %s has no checksum for %s", tagName, assetName)
+	return ""
+}
+
+// downloadAsset writes the asset to p and verifies it against the checksum.
+// An empty checksum is not verified.
+func (d GithubReleaseDownloader) downloadAsset(assetName, URL, p, checksum string) {
+	resp := d.get(URL)
+	defer func() {
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+	}()
+
 	f, err := os.Create(p)
 	if err != nil {
 		d.log.Fatalf("failed to create temp file: %s", err)
@@ -105,25 +335,155 @@ func (d GithubReleaseDownloader) downloadAsset(assetName, URL, p string) {
 		f.Close()
 	}()
 
-	_, err = io.Copy(f, resp.Body)
+	h := sha256.New()
+	_, err = io.Copy(io.MultiWriter(f, h), resp.Body)
 	if err != nil {
 		d.log.Fatalf("failed to read github asset %s: %s", assetName, err)
 	}
 
+	actual := hex.EncodeToString(h.Sum(nil))
+	if checksum != "" && actual != checksum {
+		os.Remove(p)
+		d.log.Fatalf("checksum mismatch for %s: expected %s, got %s", assetName, checksum, actual)
+	}
+
 	err = f.Chmod(os.ModePerm)
 	if err != nil {
 		d.log.Fatalf("failed to make %s executable: %s", assetName, err)
 	}
 }
 
+// get sends a GET request and fatally logs unless it succeeds.
+func (d GithubReleaseDownloader) get(URL string) *http.Response {
+	resp := d.do(URL)
+	d.checkStatus(resp)
+
+	return resp
+}
+
+func (d GithubReleaseDownloader) do(URL string) *http.Response {
+	req, err := http.NewRequest(http.MethodGet, URL, nil)
+	if err != nil {
+		d.log.Fatalf("failed to create request to github: %s", err)
+	}
+
+	if token := d.authToken(); token != "" && d.releasesHost() == req.URL.Host {
+		req.Header.Set("Authorization", "token "+token)
+	}
+
+	resp, err := d.c.Do(req)
+	if err != nil {
+		d.log.Fatalf("failed to read from github: %s", err)
+	}
+
+	return resp
+}
+
+func (d GithubReleaseDownloader) checkStatus(resp *http.Response) {
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+		d.log.Fatalf("unexpected status code (%d) from github", resp.StatusCode)
+	}
+}
+
+// authToken returns the token sent to the host of the releases URL.
+func (d GithubReleaseDownloader) authToken() string {
+	if d.token != "" {
+		return d.token
+	}
+
+	if d.releasesHost() == githubAPIHost {
+		return d.githubToken
+	}
+
+	return ""
+}
+
+func (d GithubReleaseDownloader) releasesHost() string {
+	u, err := url.Parse(d.releasesURL)
+	if err != nil {
+		return ""
+	}
+
+	return u.Host
+}
+
+func fileChecksum(p string) (string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(out, in)
+	if err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
+
+const (
+	githubAPIHost      = "api.github.com"
+	defaultReleasesURL = "https://" + githubAPIHost + "/repos/cloudfoundry/cf-drain-cli/releases"
+)
+
+// checksumsAssetName is the release asset holding the SHA-256 checksums of
+// the other assets.
+const checksumsAssetName = "checksums.txt"
+
 type githubRelease struct {
-	TagName string `json:"tag_name"`
-	Assets  []struct {
+	TagName    string `json:"tag_name"`
+	Draft      bool   `json:"draft"`
+	Prerelease bool   `json:"prerelease"`
+	Assets     []struct {
 		Name               string `json:"name"`
 		BrowserDownloadURL string `json:"browser_download_url"`
 	} `json:"assets"`
 }
 
+func (r githubRelease) assetURL(name string) (string, bool) {
+	for _, asset := range r.Assets {
+		if asset.Name == name {
+			return asset.BrowserDownloadURL, true
+		}
+	}
+
+	return "", false
+}
+
+// isPreRelease reports whether GitHub marks the release as a pre-release
+// or its tag has a pre-release version.
+func (r githubRelease) isPreRelease() bool {
+	v, ok := parseSemver(r.TagName)
+	return r.Prerelease || (ok && v.isPreRelease())
+}
+
+// githubReleases sorts releases from the newest to the oldest version.
+// Tags that are not semantic versions are sorted after all others.
 type githubReleases []githubRelease
 
 func (r githubReleases) Len() int {
@@ -131,38 +491,15 @@ func (r githubReleases) Len() int {
 }
 
 func (r githubReleases) Swap(i, j int) {
-	t := r[i]
-	r[i] = r[j]
-	r[j] = t
+	r[i], r[j] = r[j], r[i]
 }
 
 func (r githubReleases) Less(a, b int) bool {
-	ta := r.convertToInts(r[a].TagName)
-	tb := r.convertToInts(r[b].TagName)
-
-	for i := range tb {
-		if i > len(ta) {
-			return false
-		}
-
-		if tb[i] == ta[i] {
-			continue
-		}
-
-		return tb[i] < ta[i]
+	va, okA := parseSemver(r[a].TagName)
+	vb, okB := parseSemver(r[b].TagName)
+	if !okA || !okB {
+		return okA && !okB
 	}
 
-	return ta[len(tb)-1] != 0
-}
-
-func (r githubReleases) convertToInts(tagName string) []uint64 {
-	s := strings.Split(strings.Trim(tagName, "v"), ".")
-
-	var result []uint64
-	for _, ss := range s {
-		// Non-numeric values get to be a 0
-		u, _ := strconv.ParseUint(ss, 10, 64)
-		result = append(result, u)
-	}
-	return result
+	return vb.less(va)
 }
@@ -1,13 +1,19 @@
 package command_test
 
 import (
-	"errors"
-	"io"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"path"
+	"path/filepath"
 	"strings"
+	"sync"
 
 	"code.cloudfoundry.org/cf-drain-cli/internal/command"
 
@@ -17,33 +23,35 @@ import (
 
 var _ = Describe("GithubReleaseDownloader", func() {
 	var (
-		httpClient *spyHTTPClient
-		logger     *stubLogger
-		d          command.GithubReleaseDownloader
+		github *fakeGithub
+		logger *stubLogger
+		d      command.GithubReleaseDownloader
 	)
 
 	BeforeEach(func() {
-		httpClient = newSpyHTTPClient()
+		github = newFakeGithub()
+		github.releases = releasesResponse
+		github.files["/cloudfoundry/cf-drain-cli/releases/download/v0.5/space_drain"] = "Github File"
+		github.files["/cloudfoundry/cf-drain-cli/releases/download/v0.5/syslog_forwarder"] = "Github Forwarder"
+		github.files["/cloudfoundry/cf-drain-cli/releases/download/v0.5/checksums.txt"] = checksums(map[string]string{
+			"space_drain":      "Github File",
+			"syslog_forwarder": "Github Forwarder",
+		})
+		github.files["/cloudfoundry/cf-drain-cli/releases/download/v0.4.1/space_drain"] = "Old Github File"
+		github.files["/cloudfoundry/cf-drain-cli/releases/download/v0.4.1/checksums.txt"] = checksums(map[string]string{
+			"space_drain": "Old Github File",
+		})
+
 		logger = &stubLogger{}
-		d = command.NewGithubReleaseDownloader(httpClient, logger)
+		d = command.NewGithubReleaseDownloader(github.client(), logger)
 	})
 
-	It("returns a directory path to the latest release", func() {
-		httpClient.m["https://api.github.com/repos/cloudfoundry/cf-drain-cli/releases"] = httpResponse{
-			r: &http.Response{
-				StatusCode: 200,
-				Body:       releasesResponse(),
-			},
-		}
-
-		httpClient.m["https://github.com/cloudfoundry/cf-drain-cli/releases/download/v0.5/space_drain"] = httpResponse{
-			r: &http.Response{
-				StatusCode: 200,
-				Body:       ioutil.NopCloser(strings.NewReader(`Github File`)),
-			},
-		}
+	AfterEach(func() {
+		github.server.Close()
+	})
 
-		p := d.Download("space_drain")
+	It("returns a directory path to the latest release", func() {
+		p := d.Download("space_drain", "", false, false)
 		Expect(path.Base(p)).To(Equal("space_drain"))
 
 		file, err := os.Open(p)
@@ -60,95 +68,535 @@ var _ = Describe("GithubReleaseDownloader", func() {
 	})
 
 	It("works for any asset on the release", func() {
-		httpClient.m["https://api.github.com/repos/cloudfoundry/cf-drain-cli/releases"] = httpResponse{
-			r: &http.Response{
-				StatusCode: 200,
-				Body:       releasesResponse(),
-			},
-		}
+		p := d.Download("syslog_forwarder", "", false, false)
+		Expect(path.Base(p)).To(Equal("syslog_forwarder"))
 
-		httpClient.m["https://github.com/cloudfoundry/cf-drain-cli/releases/download/v0.5/syslog_forwarder"] = httpResponse{
-			r: &http.Response{
-				StatusCode: 200,
-				Body:       ioutil.NopCloser(strings.NewReader(`Github File`)),
-			},
-		}
+		contents, err := ioutil.ReadFile(p)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(contents)).To(Equal("Github Forwarder"))
+	})
 
-		p := d.Download("syslog_forwarder")
-		Expect(path.Base(p)).To(Equal("syslog_forwarder"))
+	It("downloads the asset from the given release", func() {
+		p := d.Download("space_drain", "v0.4.1", false, false)
+
+		contents, err := ioutil.ReadFile(p)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(contents)).To(Equal("Old Github File"))
+	})
+
+	It("looks up the given release by its tag instead of listing releases", func() {
+		github.releases = "[]"
+		github.tagged["v0.4.1"] = `{
+      "tag_name": "v0.4.1",
+      "assets": [
+        {"name": "space_drain", "browser_download_url": "https://github.com/cloudfoundry/cf-drain-cli/releases/download/v0.4.1/space_drain"},
+        {"name": "checksums.txt", "browser_download_url": "https://github.com/cloudfoundry/cf-drain-cli/releases/download/v0.4.1/checksums.txt"}
+      ]
+    }`
+
+		p := d.Download("space_drain", "v0.4.1", false, false)
+
+		contents, err := ioutil.ReadFile(p)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(contents)).To(Equal("Old Github File"))
+		Expect(github.hitCount("/repos/cloudfoundry/cf-drain-cli/releases")).To(Equal(0))
+		Expect(github.hitCount("/repos/cloudfoundry/cf-drain-cli/releases/tags/v0.4.1")).To(Equal(1))
+	})
+
+	It("fatally logs when the given release does not exist", func() {
+		Expect(func() {
+			d.Download("space_drain", "v9.9.9", false, false)
+		}).To(Panic())
+		Expect(logger.fatalfMessage).To(Equal("unable to find release v9.9.9"))
+	})
+
+	It("fatally logs when the given release does not have the asset", func() {
+		Expect(func() {
+			d.Download("syslog_forwarder", "v0.4.1", false, false)
+		}).To(Panic())
+		Expect(logger.fatalfMessage).To(Equal("unable to find syslog_forwarder asset in release v0.4.1"))
+	})
+
+	It("fatally logs when the asset does not match its checksum", func() {
+		github.files["/cloudfoundry/cf-drain-cli/releases/download/v0.5/space_drain"] = "Tampered File"
+
+		Expect(func() {
+			d.Download("space_drain", "", false, false)
+		}).To(Panic())
+		Expect(logger.fatalfMessage).To(HavePrefix(fmt.Sprintf(
+			"checksum mismatch for space_drain: expected %s, got ",
+			sha256Hex("Github File"),
+		)))
+	})
+
+	It("fatally logs when the checksums do not list the asset", func() {
+		github.files["/cloudfoundry/cf-drain-cli/releases/download/v0.5/checksums.txt"] = checksums(map[string]string{
+			"syslog_forwarder": "Github Forwarder",
+		})
+
+		Expect(func() {
+			d.Download("space_drain", "", false, false)
+		}).To(Panic())
+		Expect(logger.fatalfMessage).To(Equal("release v0.5 has no checksum for space_drain"))
+	})
+
+	It("fatally logs when the release has no checksums", func() {
+		github.releases = releasesResponseNoChecksums
+
+		Expect(func() {
+			d.Download("space_drain", "", false, false)
+		}).To(Panic())
+		Expect(logger.fatalfMessage).To(Equal(
+			"release v0.5 has no checksums.txt to verify space_drain, use --skip-checksum to use it without verifying it",
+		))
+	})
+
+	It("downloads a release without checksums when skipping the checksum", func() {
+		github.releases = releasesResponseNoChecksums
+
+		p := d.Download("space_drain", "", false, true)
+
+		contents, err := ioutil.ReadFile(p)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(contents)).To(Equal("Github File"))
+		Expect(logger.printfMessages).To(ContainElement(
+			"WARNING: not verifying the checksum of space_drain v0.5, --skip-checksum was given.",
+		))
+	})
+
+	It("does not verify the asset when skipping the checksum", func() {
+		github.files["/cloudfoundry/cf-drain-cli/releases/download/v0.5/space_drain"] = "Tampered File"
+
+		p := d.Download("space_drain", "", false, true)
+
+		contents, err := ioutil.ReadFile(p)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(contents)).To(Equal("Tampered File"))
+		Expect(github.hitCount("/cloudfoundry/cf-drain-cli/releases/download/v0.5/checksums.txt")).To(Equal(0))
 	})
 
 	It("fatally logs when fetching releases returns a non-200", func() {
-		httpClient.m["https://api.github.com/repos/cloudfoundry/cf-drain-cli/releases"] = httpResponse{
-			r: &http.Response{StatusCode: 404},
-		}
+		github.statuses["/repos/cloudfoundry/cf-drain-cli/releases"] = http.StatusNotFound
 
 		Expect(func() {
-			d.Download("space_drain")
+			d.Download("space_drain", "", false, false)
 		}).To(Panic())
 		Expect(logger.fatalfMessage).To(Equal("unexpected status code (404) from github"))
 	})
 
 	It("fatally logs when fetching the latest asset returns a non-200", func() {
-		httpClient.m["https://api.github.com/repos/cloudfoundry/cf-drain-cli/releases"] = httpResponse{
-			r: &http.Response{
-				StatusCode: 200,
-				Body:       releasesResponse(),
-			},
-		}
-
-		httpClient.m["https://github.com/cloudfoundry/cf-drain-cli/releases/download/v0.5/space_drain"] = httpResponse{
-			r: &http.Response{StatusCode: 404},
-		}
+		delete(github.files, "/cloudfoundry/cf-drain-cli/releases/download/v0.5/space_drain")
 
 		Expect(func() {
-			d.Download("space_drain")
+			d.Download("space_drain", "", false, false)
 		}).To(Panic())
 		Expect(logger.fatalfMessage).To(Equal("unexpected status code (404) from github"))
 	})
 
 	It("fatally logs when it can't find the space drain", func() {
-		httpClient.m["https://api.github.com/repos/cloudfoundry/cf-drain-cli/releases"] = httpResponse{
-			r: &http.Response{
-				StatusCode: 200,
-				Body:       releasesResponseNoSpaceDrain(),
-			},
-		}
+		github.releases = releasesResponseNoSpaceDrain
 
 		Expect(func() {
-			d.Download("space_drain")
+			d.Download("space_drain", "", false, false)
 		}).To(Panic())
 		Expect(logger.fatalfMessage).To(Equal("unable to find space_drain asset in releases"))
 	})
 
 	It("fatally logs when decoding releases fails", func() {
-		httpClient.m["https://api.github.com/repos/cloudfoundry/cf-drain-cli/releases"] = httpResponse{
-			r: &http.Response{
-				StatusCode: 200,
-				Body:       ioutil.NopCloser(strings.NewReader("invalid")),
-			},
-		}
+		github.releases = "invalid"
 
 		Expect(func() {
-			d.Download("space_drain")
+			d.Download("space_drain", "", false, false)
 		}).To(Panic())
 		Expect(logger.fatalfMessage).To(Equal("failed to decode releases response from github"))
 	})
 
-	It("fatally logs when github returns an error", func() {
-		httpClient.m["https://api.github.com/repos/cloudfoundry/cf-drain-cli/releases"] = httpResponse{
-			err: errors.New("some error"),
-		}
+	It("lists releases from the configured URL", func() {
+		github.releasesPath = "/api/v3/repos/mirror/cf-drain-cli/releases"
+		d = command.NewGithubReleaseDownloader(
+			http.DefaultClient,
+			logger,
+			command.WithReleasesURL(github.server.URL+"/api/v3/repos/mirror/cf-drain-cli/releases"),
+		)
+		github.releases = strings.Replace(releasesResponse, "https://github.com", github.server.URL, -1)
+
+		p := d.Download("space_drain", "", false, false)
+
+		contents, err := ioutil.ReadFile(p)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(contents)).To(Equal("Github File"))
+		Expect(github.hitCount("/api/v3/repos/mirror/cf-drain-cli/releases")).To(Equal(1))
+	})
+
+	It("sends the token only to the host of the releases URL", func() {
+		d = command.NewGithubReleaseDownloader(github.client(), logger, command.WithGithubToken("some-token"))
+
+		d.Download("space_drain", "", false, false)
+
+		Expect(github.auth("/repos/cloudfoundry/cf-drain-cli/releases")).To(Equal("token some-token"))
+		Expect(github.auth("/cloudfoundry/cf-drain-cli/releases/download/v0.5/space_drain")).To(BeEmpty())
+	})
+
+	It("sends GITHUB_TOKEN to api.github.com", func() {
+		d = command.NewGithubReleaseDownloader(github.client(), logger, command.WithGithubComToken("ambient-token"))
+
+		d.Download("space_drain", "", false, false)
+
+		Expect(github.auth("/repos/cloudfoundry/cf-drain-cli/releases")).To(Equal("token ambient-token"))
+	})
+
+	It("prefers the configured token over GITHUB_TOKEN", func() {
+		d = command.NewGithubReleaseDownloader(
+			github.client(),
+			logger,
+			command.WithGithubToken("some-token"),
+			command.WithGithubComToken("ambient-token"),
+		)
+
+		d.Download("space_drain", "", false, false)
+
+		Expect(github.auth("/repos/cloudfoundry/cf-drain-cli/releases")).To(Equal("token some-token"))
+	})
+
+	It("does not send GITHUB_TOKEN to a mirror", func() {
+		github.releasesPath = "/api/v3/repos/mirror/cf-drain-cli/releases"
+		d = command.NewGithubReleaseDownloader(
+			github.client(),
+			logger,
+			command.WithReleasesURL("https://github.example.com/api/v3/repos/mirror/cf-drain-cli/releases"),
+			command.WithGithubComToken("ambient-token"),
+		)
+
+		d.Download("space_drain", "", false, false)
+
+		Expect(github.hitCount("/api/v3/repos/mirror/cf-drain-cli/releases")).To(Equal(1))
+		Expect(github.auth("/api/v3/repos/mirror/cf-drain-cli/releases")).To(BeEmpty())
+	})
+
+	It("does not send a token by default", func() {
+		d.Download("space_drain", "", false, false)
+
+		Expect(github.auth("/repos/cloudfoundry/cf-drain-cli/releases")).To(BeEmpty())
+	})
+
+	Context("with a cache directory", func() {
+		var cacheDir string
+
+		BeforeEach(func() {
+			var err error
+			cacheDir, err = ioutil.TempDir("", "drains-cache")
+			Expect(err).ToNot(HaveOccurred())
+
+			d = command.NewGithubReleaseDownloader(github.client(), logger, command.WithCacheDir(cacheDir))
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(cacheDir)
+		})
+
+		It("keeps the asset in a directory of its own under the release", func() {
+			p := d.Download("space_drain", "", false, false)
+			Expect(p).To(Equal(filepath.Join(cacheDir, "v0.5", "space_drain", "space_drain")))
+
+			contents, err := ioutil.ReadFile(p)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(contents)).To(Equal("Github File"))
+
+			files, err := ioutil.ReadDir(filepath.Dir(p))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(files).To(HaveLen(1))
+		})
+
+		It("does not cache an asset downloaded without verifying it", func() {
+			github.files["/cloudfoundry/cf-drain-cli/releases/download/v0.5/space_drain"] = "Tampered File"
+
+			p := d.Download("space_drain", "v0.5", false, true)
+			Expect(p).ToNot(HavePrefix(cacheDir))
+			_, err := os.Stat(filepath.Join(cacheDir, "v0.5"))
+			Expect(os.IsNotExist(err)).To(BeTrue())
+
+			Expect(func() {
+				d.Download("space_drain", "v0.5", false, false)
+			}).To(Panic())
+			Expect(logger.fatalfMessage).To(HavePrefix("checksum mismatch for space_drain"))
+			Expect(github.hitCount("/cloudfoundry/cf-drain-cli/releases/download/v0.5/space_drain")).To(Equal(2))
+		})
+
+		It("only downloads the latest release once", func() {
+			d.Download("space_drain", "", false, false)
+			p := d.Download("space_drain", "", false, false)
+
+			Expect(p).To(Equal(filepath.Join(cacheDir, "v0.5", "space_drain", "space_drain")))
+			Expect(github.hitCount("/cloudfoundry/cf-drain-cli/releases/download/v0.5/space_drain")).To(Equal(1))
+		})
+
+		It("uses a cached version without contacting github", func() {
+			d.Download("space_drain", "v0.4.1", false, false)
+			github.server.Close()
+
+			p := d.Download("space_drain", "v0.4.1", false, false)
+
+			contents, err := ioutil.ReadFile(p)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(contents)).To(Equal("Old Github File"))
+		})
+
+		It("downloads the asset again if the cached copy was changed", func() {
+			p := d.Download("space_drain", "v0.4.1", false, false)
+			Expect(ioutil.WriteFile(p, []byte("corrupt"), 0755)).To(Succeed())
+
+			p = d.Download("space_drain", "v0.4.1", false, false)
+
+			contents, err := ioutil.ReadFile(p)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(contents)).To(Equal("Old Github File"))
+			Expect(github.hitCount("/cloudfoundry/cf-drain-cli/releases/download/v0.4.1/space_drain")).To(Equal(2))
+		})
+	})
+
+	Context("with pre-releases and odd tags", func() {
+		BeforeEach(func() {
+			tags := []string{"v1.9.0", "nightly", "v1.10.0-rc.1", "v1.10.0-rc.2", "v1.2", "v2.0.0", "v1.10.0", "", "v1.x.0", "v1.10.0-beta"}
+			for _, tag := range tags {
+				contents := "space_drain " + tag
+				github.files["/download/"+tag+"/space_drain"] = contents
+				github.files["/download/"+tag+"/checksums.txt"] = checksums(map[string]string{"space_drain": contents})
+			}
+
+			github.releases = releases(tags, map[string]bool{"v2.0.0": true}, nil)
+		})
+
+		It("downloads the newest release by semantic version", func() {
+			p := d.Download("space_drain", "", false, false)
+
+			contents, err := ioutil.ReadFile(p)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(contents)).To(Equal("space_drain v1.10.0"))
+		})
+
+		It("skips drafts", func() {
+			Expect(func() {
+				d.Download("space_drain", "v2.0.0", false, false)
+			}).To(Panic())
+			Expect(logger.fatalfMessage).To(Equal("unable to find release v2.0.0"))
+		})
+
+		It("downloads the newest pre-release if asked to", func() {
+			github.releases = releases(
+				[]string{"v1.9.0", "v1.10.0-rc.1", "v1.10.0-rc.2", "v1.10.0-beta"},
+				nil,
+				nil,
+			)
+
+			p := d.Download("space_drain", "", true, false)
+
+			contents, err := ioutil.ReadFile(p)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(contents)).To(Equal("space_drain v1.10.0-rc.2"))
+		})
+
+		It("skips releases marked as pre-releases on github", func() {
+			github.releases = releases(
+				[]string{"v1.9.0", "v1.10.0"},
+				nil,
+				map[string]bool{"v1.10.0": true},
+			)
+
+			p := d.Download("space_drain", "", false, false)
+
+			contents, err := ioutil.ReadFile(p)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(contents)).To(Equal("space_drain v1.9.0"))
+		})
+
+		It("downloads a pinned pre-release", func() {
+			p := d.Download("space_drain", "v1.10.0-rc.1", false, false)
+
+			contents, err := ioutil.ReadFile(p)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(contents)).To(Equal("space_drain v1.10.0-rc.1"))
+		})
+
+		It("falls back to tags that are not semantic versions", func() {
+			github.releases = releases([]string{"nightly"}, nil, nil)
+
+			p := d.Download("space_drain", "", false, false)
+
+			contents, err := ioutil.ReadFile(p)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(contents)).To(Equal("space_drain nightly"))
+		})
+	})
+
+	It("fatally logs when github can not be reached", func() {
+		github.server.Close()
 
 		Expect(func() {
-			d.Download("space_drain")
+			d.Download("space_drain", "", false, false)
 		}).To(Panic())
-		Expect(logger.fatalfMessage).To(Equal("failed to read from github: some error"))
+		Expect(logger.fatalfMessage).To(HavePrefix("failed to read from github: "))
 	})
 })
 
-func releasesResponse() io.ReadCloser {
-	return ioutil.NopCloser(strings.NewReader(`
+// fakeGithub serves releases and their assets. Requests to api.github.com
+// and github.com are redirected to it by the client it returns.
+type fakeGithub struct {
+	server       *httptest.Server
+	releasesPath string
+	releases     string
+	// tagged holds releases that are only served by tag, as if they were
+	// not on the first page of the releases.
+	tagged   map[string]string
+	files    map[string]string
+	statuses map[string]int
+
+	mu    sync.Mutex
+	hits  map[string]int
+	auths map[string]string
+}
+
+func newFakeGithub() *fakeGithub {
+	g := &fakeGithub{
+		releasesPath: "/repos/cloudfoundry/cf-drain-cli/releases",
+		tagged:       make(map[string]string),
+		files:        make(map[string]string),
+		statuses:     make(map[string]int),
+		hits:         make(map[string]int),
+		auths:        make(map[string]string),
+	}
+	g.server = httptest.NewServer(http.HandlerFunc(g.serveHTTP))
+
+	return g
+}
+
+func (g *fakeGithub) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	g.mu.Lock()
+	g.hits[r.URL.Path]++
+	g.auths[r.URL.Path] = r.Header.Get("Authorization")
+	g.mu.Unlock()
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if status, ok := g.statuses[r.URL.Path]; ok {
+		w.WriteHeader(status)
+		return
+	}
+
+	if r.URL.Path == g.releasesPath {
+		w.Write([]byte(g.releases))
+		return
+	}
+
+	if strings.HasPrefix(r.URL.Path, g.releasesPath+"/tags/") {
+		g.serveRelease(w, strings.TrimPrefix(r.URL.Path, g.releasesPath+"/tags/"))
+		return
+	}
+
+	body, ok := g.files[r.URL.Path]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Write([]byte(body))
+}
+
+// serveRelease serves the release with the tag from the releases, like the
+// /releases/tags/{tag} endpoint. Drafts can not be fetched by tag.
+func (g *fakeGithub) serveRelease(w http.ResponseWriter, tag string) {
+	if r, ok := g.tagged[tag]; ok {
+		w.Write([]byte(r))
+		return
+	}
+
+	var releases []map[string]interface{}
+	if err := json.Unmarshal([]byte(g.releases), &releases); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	for _, r := range releases {
+		if r["tag_name"] == tag && r["draft"] != true {
+			json.NewEncoder(w).Encode(r)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNotFound)
+}
+
+func (g *fakeGithub) hitCount(path string) int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.hits[path]
+}
+
+func (g *fakeGithub) auth(path string) string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.auths[path]
+}
+
+func (g *fakeGithub) client() *http.Client {
+	u, err := url.Parse(g.server.URL)
+	if err != nil {
+		panic(err)
+	}
+
+	return &http.Client{
+		Transport: redirectTransport{target: u},
+	}
+}
+
+type redirectTransport struct {
+	target *url.URL
+}
+
+func (t redirectTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.URL.Scheme = t.target.Scheme
+	r.URL.Host = t.target.Host
+
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+// releases returns a releases response with a space_drain asset and its
+// checksums for each tag.
+func releases(tags []string, drafts, preReleases map[string]bool) string {
+	var rs []string
+	for _, tag := range tags {
+		rs = append(rs, fmt.Sprintf(`{
+      "tag_name": %q,
+      "draft": %t,
+      "prerelease": %t,
+      "assets": [
+        {"name": "space_drain", "browser_download_url": "https://github.com/download/%s/space_drain"},
+        {"name": "checksums.txt", "browser_download_url": "https://github.com/download/%s/checksums.txt"}
+      ]
+    }`, tag, drafts[tag], preReleases[tag], tag, tag))
+	}
+
+	return "[" + strings.Join(rs, ",") + "]"
+}
+
+func checksums(assets map[string]string) string {
+	var lines []string
+	for name, contents := range assets {
+		lines = append(lines, fmt.Sprintf("%s  %s", sha256Hex(contents), name))
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+const releasesResponse = `
    [
      {
       "tag_name": "v0.4.1",
@@ -162,8 +610,8 @@ func releasesResponse() io.ReadCloser {
           "browser_download_url": "https://github.com/cloudfoundry/cf-drain-cli/releases/download/v0.4.1/space_drain"
         },
         {
-          "name": "syslog_forwarder",
-          "browser_download_url": "https://github.com/cloudfoundry/cf-drain-cli/releases/download/v0.4.1/syslog_forwarder"
+          "name": "checksums.txt",
+          "browser_download_url": "https://github.com/cloudfoundry/cf-drain-cli/releases/download/v0.4.1/checksums.txt"
         }
       ]
      },
@@ -181,15 +629,31 @@ func releasesResponse() io.ReadCloser {
         {
           "name": "syslog_forwarder",
           "browser_download_url": "https://github.com/cloudfoundry/cf-drain-cli/releases/download/v0.5/syslog_forwarder"
+        },
+        {
+          "name": "checksums.txt",
+          "browser_download_url": "https://github.com/cloudfoundry/cf-drain-cli/releases/download/v0.5/checksums.txt"
         }
       ]
      }
    ]
-`))
-}
+`
 
-func releasesResponseNoSpaceDrain() io.ReadCloser {
-	return ioutil.NopCloser(strings.NewReader(`
+const releasesResponseNoChecksums = `
+   [
+     {
+      "tag_name": "v0.5",
+      "assets": [
+        {
+          "name": "space_drain",
+          "browser_download_url": "https://github.com/cloudfoundry/cf-drain-cli/releases/download/v0.5/space_drain"
+        }
+      ]
+     }
+   ]
+`
+
+const releasesResponseNoSpaceDrain = `
    [
      {
       "tag_name": "v0.5",
@@ -201,33 +665,4 @@ func releasesResponseNoSpaceDrain() io.ReadCloser {
       ]
      }
    ]
-`))
-}
-
-type httpResponse struct {
-	r   *http.Response
-	err error
-}
-
-type spyHTTPClient struct {
-	m map[string]httpResponse
-}
-
-func newSpyHTTPClient() *spyHTTPClient {
-	return &spyHTTPClient{
-		m: make(map[string]httpResponse),
-	}
-}
-
-func (s *spyHTTPClient) Do(r *http.Request) (*http.Response, error) {
-	if r.Method != http.MethodGet {
-		panic("only use GETs")
-	}
-
-	value, ok := s.m[r.URL.String()]
-	if !ok {
-		panic("unknown URL " + r.URL.String())
-	}
-
-	return value.r, value.err
-}
+`
@@ -5,6 +5,7 @@ import (
 	"path"
 	"strconv"
 	"strings"
+	"time"
 
 	flags "github.com/jessevdk/go-flags"
 
@@ -13,7 +14,7 @@ import (
 )
 
 type Downloader interface {
-	Download(assetName string) string
+	Download(assetName, version string, preRelease, skipChecksum bool) string
 }
 
 type RefreshTokenFetcher interface {
@@ -21,10 +22,21 @@ type RefreshTokenFetcher interface {
 }
 
 type pushSpaceDrainOpts struct {
-	DrainName string `long:"drain-name"`
-	DrainURL  string
-	Path      string `long:"path"`
-	DrainType string `long:"type"`
+	DrainName    string `long:"drain-name"`
+	DrainURL     string
+	Path         string `long:"path"`
+	DrainType    string `long:"type"`
+	Selector     string `long:"selector"`
+	Version      string `long:"version"`
+	PreRelease   bool   `long:"pre-release"`
+	SkipChecksum bool   `long:"skip-checksum"`
+
+	ClientID     string `long:"client-id"`
+	ClientSecret string `long:"client-secret"`
+
+	Interval   time.Duration `long:"interval"`
+	Jitter     time.Duration `long:"jitter"`
+	MaxBackoff time.Duration `long:"max-backoff"`
 }
 
 func PushSpaceDrain(
@@ -51,33 +63,85 @@ func PushSpaceDrain(
 
 	opts.DrainURL = args[0]
 
+	if opts.Version != "" && opts.Path != "" {
+		log.Fatalf("--version and --path can not be used together.")
+	}
+
+	if (opts.ClientID == "") != (opts.ClientSecret == "") {
+		log.Fatalf("--client-id and --client-secret must be given together.")
+	}
+
 	app, _ := cli.GetApp(opts.DrainName)
 	if app.Name == opts.DrainName {
 		log.Fatalf("A drain with that name already exists. Use --drain-name to create a drain with a different name.")
 	}
 
-	pushDrain(cli, opts.DrainName, "space_drain", nil, opts, d, f, log)
+	var extraEnvs [][]string
+	if opts.Selector != "" {
+		extraEnvs = append(extraEnvs, []string{"DRAIN_SELECTOR", opts.Selector})
+	}
+
+	if opts.Interval < 0 || opts.Jitter < 0 || opts.MaxBackoff < 0 {
+		log.Fatalf("--interval, --jitter and --max-backoff must not be negative.")
+	}
+
+	if opts.Interval != 0 {
+		extraEnvs = append(extraEnvs, []string{"SYNC_INTERVAL", opts.Interval.String()})
+	}
+
+	if opts.Jitter != 0 {
+		extraEnvs = append(extraEnvs, []string{"SYNC_JITTER", opts.Jitter.String()})
+	}
+
+	if opts.MaxBackoff != 0 {
+		extraEnvs = append(extraEnvs, []string{"BACKOFF_MAX", opts.MaxBackoff.String()})
+	}
+
+	pushDrain(cli, opts.DrainName, "space_drain", extraEnvs, opts, d, f, log)
 }
 
 func pushDrain(cli plugin.CliConnection, appName, command string, extraEnvs [][]string, opts pushSpaceDrainOpts, d Downloader, f RefreshTokenFetcher, log Logger) {
-	if opts.Path == "" {
-		log.Printf("Downloading latest space drain from github...")
-		opts.Path = path.Dir(d.Download(command))
+	pushDrainApp(cli, appName, command, opts.Path, opts.Version, opts.PreRelease, opts.SkipChecksum, d, log)
+
+	space := currentSpace(cli, log)
+	setDrainEnvs(cli, appName, space.Guid, extraEnvs, opts, f, log)
+
+	cli.CliCommand("start", appName)
+}
+
+// pushDrainApp pushes the drain binary without starting it. The given
+// release, or the latest one, is downloaded from github when no path is
+// given. The app's health check uses its /health endpoint so that a wedged
+// space drain is restarted.
+func pushDrainApp(cli plugin.CliConnection, appName, command, appPath, version string, preRelease, skipChecksum bool, d Downloader, log Logger) {
+	if appPath == "" {
+		if version != "" {
+			log.Printf("Downloading space drain %s from github...", version)
+		} else {
+			log.Printf("Downloading latest space drain from github...")
+		}
+		appPath = path.Dir(d.Download(command, version, preRelease, skipChecksum))
 		log.Printf("Done downloading space drain from github.")
 	}
 
 	_, err := cli.CliCommand(
 		"push", appName,
-		"-p", opts.Path,
+		"-p", appPath,
 		"-b", "binary_buildpack",
 		"-c", fmt.Sprint("./", command),
+		"-u", "http",
+		"--endpoint", "/health",
 		"--no-start",
 	)
 	if err != nil {
 		log.Fatalf("%s", err)
 	}
+}
 
-	space := currentSpace(cli, log)
+// setDrainEnvs sets the env vars every space drain needs, followed by any
+// extra ones. The space drain authenticates with the UAA client given in
+// opts or, without one, with the user's refresh token.
+func setDrainEnvs(cli plugin.CliConnection, appName, spaceGuid string, extraEnvs [][]string, opts pushSpaceDrainOpts, f RefreshTokenFetcher, log Logger) {
 	api := apiEndpoint(cli, log)
 
 	skipCertVerify, err := cli.IsSSLDisabled()
@@ -85,33 +149,43 @@ func pushDrain(cli plugin.CliConnection, appName, command string, extraEnvs [][]
 		log.Fatalf("%s", err)
 	}
 
-	refreshToken, err := f.RefreshToken()
-	if err != nil {
-		log.Fatalf("%s", err)
+	var credentialEnvs [][]string
+	if opts.ClientSecret != "" {
+		credentialEnvs = [][]string{
+			{"CLIENT_ID", opts.ClientID},
+			{"CLIENT_SECRET", opts.ClientSecret},
+		}
+	} else {
+		refreshToken, err := f.RefreshToken()
+		if err != nil {
+			log.Fatalf("%s", err)
+		}
+
+		credentialEnvs = [][]string{
+			{"CLIENT_ID", "cf"},
+			{"REFRESH_TOKEN", refreshToken},
+		}
 	}
 
 	sharedEnvs := [][]string{
-		{"SPACE_ID", space.Guid},
+		{"SPACE_ID", spaceGuid},
 		{"DRAIN_NAME", opts.DrainName},
 		{"DRAIN_URL", opts.DrainURL},
 		{"DRAIN_TYPE", opts.DrainType},
 		{"API_ADDR", api},
 		{"UAA_ADDR", strings.Replace(api, "api", "uaa", 1)},
-		{"CLIENT_ID", "cf"},
-		{"REFRESH_TOKEN", refreshToken},
 		{"SKIP_CERT_VERIFY", strconv.FormatBool(skipCertVerify)},
 		{"DRAIN_SCOPE", "space"},
 	}
 
-	envs := append(sharedEnvs, extraEnvs...)
+	envs := append(sharedEnvs, credentialEnvs...)
+	envs = append(envs, extraEnvs...)
 	for _, env := range envs {
 		_, err := cli.CliCommandWithoutTerminalOutput("set-env", appName, env[0], env[1])
 		if err != nil {
 			log.Fatalf("%s", err)
 		}
 	}
-
-	cli.CliCommand("start", appName)
 }
 
 func currentSpace(cli plugin.CliConnection, log Logger) plugin_models.Space {
@@ -52,6 +52,8 @@ var _ = Describe("PushSpaceDrain", func() {
 				"-p", "some-temp-dir",
 				"-b", "binary_buildpack",
 				"-c", "./space_drain",
+				"-u", "http",
+				"--endpoint", "/health",
 				"--no-start",
 			},
 		))
@@ -76,6 +78,67 @@ var _ = Describe("PushSpaceDrain", func() {
 		))
 	})
 
+	It("configures the space drain with UAA client credentials", func() {
+		command.PushSpaceDrain(
+			cli,
+			[]string{
+				"https://some-drain",
+				"--path", "some-temp-dir",
+				"--drain-name", "some-drain",
+				"--client-id", "some-client",
+				"--client-secret", "some-secret",
+			},
+			downloader,
+			refreshTokenFetcher,
+			logger,
+		)
+
+		Expect(cli.cliCommandWithoutTerminalOutputArgs).To(ConsistOf(
+			[]string{"set-env", "some-drain", "SPACE_ID", "space-guid"},
+			[]string{"set-env", "some-drain", "DRAIN_NAME", "some-drain"},
+			[]string{"set-env", "some-drain", "DRAIN_URL", "https://some-drain"},
+			[]string{"set-env", "some-drain", "DRAIN_TYPE", "all"},
+			[]string{"set-env", "some-drain", "API_ADDR", "https://api.something.com"},
+			[]string{"set-env", "some-drain", "UAA_ADDR", "https://uaa.something.com"},
+			[]string{"set-env", "some-drain", "CLIENT_ID", "some-client"},
+			[]string{"set-env", "some-drain", "CLIENT_SECRET", "some-secret"},
+			[]string{"set-env", "some-drain", "SKIP_CERT_VERIFY", "false"},
+			[]string{"set-env", "some-drain", "DRAIN_SCOPE", "space"},
+		))
+	})
+
+	It("does not need a refresh token with client credentials", func() {
+		refreshTokenFetcher.err = errors.New("no token")
+
+		command.PushSpaceDrain(
+			cli,
+			[]string{
+				"https://some-drain",
+				"--path", "some-temp-dir",
+				"--client-id", "some-client",
+				"--client-secret", "some-secret",
+			},
+			downloader,
+			refreshTokenFetcher,
+			logger,
+		)
+
+		Expect(logger.fatalfMessage).To(BeEmpty())
+	})
+
+	It("fatally logs if only one of the client id and secret is given", func() {
+		Expect(func() {
+			command.PushSpaceDrain(
+				cli,
+				[]string{"https://some-drain", "--client-id", "some-client"},
+				downloader,
+				refreshTokenFetcher,
+				logger,
+			)
+		}).To(Panic())
+		Expect(logger.fatalfMessage).To(Equal("--client-id and --client-secret must be given together."))
+	})
+
 	It("downloads the app before pushing app from the given space-drain directory", func() {
 		command.PushSpaceDrain(
 			cli,
@@ -90,6 +153,7 @@ var _ = Describe("PushSpaceDrain", func() {
 		)
 
 		Expect(downloader.assetName).To(Equal("space_drain"))
+		Expect(downloader.version).To(BeEmpty())
 
 		Expect(cli.cliCommandArgs).To(HaveLen(2))
 		Expect(cli.cliCommandArgs[0]).To(Equal(
@@ -98,11 +162,70 @@ var _ = Describe("PushSpaceDrain", func() {
 				"-p", "/downloaded/temp/dir",
 				"-b", "binary_buildpack",
 				"-c", "./space_drain",
+				"-u", "http",
+				"--endpoint", "/health",
 				"--no-start",
 			},
 		))
 	})
 
+	It("downloads the given version of the app", func() {
+		command.PushSpaceDrain(
+			cli,
+			[]string{
+				"https://some-drain",
+				"--drain-name", "some-drain",
+				"--version", "v1.2.3",
+			},
+			downloader,
+			refreshTokenFetcher,
+			logger,
+		)
+
+		Expect(downloader.assetName).To(Equal("space_drain"))
+		Expect(downloader.version).To(Equal("v1.2.3"))
+		Expect(logger.printfMessages).To(ContainElement("Downloading space drain v1.2.3 from github..."))
+	})
+
+	It("downloads the latest pre-release if asked to", func() {
+		command.PushSpaceDrain(
+			cli,
+			[]string{"https://some-drain", "--pre-release"},
+			downloader,
+			refreshTokenFetcher,
+			logger,
+		)
+
+		Expect(downloader.preRelease).To(BeTrue())
+		Expect(downloader.skipChecksum).To(BeFalse())
+	})
+
+	It("skips the checksum if asked to", func() {
+		command.PushSpaceDrain(
+			cli,
+			[]string{"https://some-drain", "--skip-checksum"},
+			downloader,
+			refreshTokenFetcher,
+			logger,
+		)
+
+		Expect(downloader.skipChecksum).To(BeTrue())
+	})
+
+	It("fatally logs if both a version and a path are given", func() {
+		Expect(func() {
+			command.PushSpaceDrain(
+				cli,
+				[]string{"https://some-drain", "--version", "v1.2.3", "--path", "some-temp-dir"},
+				downloader,
+				refreshTokenFetcher,
+				logger,
+			)
+		}).To(Panic())
+		Expect(logger.fatalfMessage).To(Equal("--version and --path can not be used together."))
+		Expect(downloader.assetName).To(BeEmpty())
+	})
+
 	It("pushes downloaded app", func() {
 		command.PushSpaceDrain(
 			cli,
@@ -123,6 +246,8 @@ var _ = Describe("PushSpaceDrain", func() {
 				"-p", "/downloaded/temp/dir",
 				"-b", "binary_buildpack",
 				"-c", "./space_drain",
+				"-u", "http",
+				"--endpoint", "/health",
 				"--no-start",
 			},
 		))
@@ -167,6 +292,8 @@ var _ = Describe("PushSpaceDrain", func() {
 				"-p", "some-temp-dir",
 				"-b", "binary_buildpack",
 				"-c", "./space_drain",
+				"-u", "http",
+				"--endpoint", "/health",
 				"--no-start",
 			},
 		))
@@ -182,6 +309,63 @@ var _ = Describe("PushSpaceDrain", func() {
 		))
 	})
 
+	It("sets the drain selector", func() {
+		command.PushSpaceDrain(
+			cli,
+			[]string{
+				"https://some-drain",
+				"--path", "some-temp-dir",
+				"--selector", "env=prod",
+			},
+			downloader,
+			refreshTokenFetcher,
+			logger,
+		)
+
+		Expect(cli.cliCommandWithoutTerminalOutputArgs).To(ContainElement(
+			[]string{"set-env", "space-drain", "DRAIN_SELECTOR", "env=prod"},
+		))
+	})
+
+	It("sets the sync interval, jitter and backoff", func() {
+		command.PushSpaceDrain(
+			cli,
+			[]string{
+				"https://some-drain",
+				"--path", "some-temp-dir",
+				"--interval", "5m",
+				"--jitter", "30s",
+				"--max-backoff", "2m",
+			},
+			downloader,
+			refreshTokenFetcher,
+			logger,
+		)
+
+		Expect(cli.cliCommandWithoutTerminalOutputArgs).To(ContainElement(
+			[]string{"set-env", "space-drain", "SYNC_INTERVAL", "5m0s"},
+		))
+		Expect(cli.cliCommandWithoutTerminalOutputArgs).To(ContainElement(
+			[]string{"set-env", "space-drain", "SYNC_JITTER", "30s"},
+		))
+		Expect(cli.cliCommandWithoutTerminalOutputArgs).To(ContainElement(
+			[]string{"set-env", "space-drain", "BACKOFF_MAX", "2m0s"},
+		))
+	})
+
+	It("fatally logs for an invalid interval", func() {
+		Expect(func() {
+			command.PushSpaceDrain(
+				cli,
+				[]string{"https://some-drain", "--interval", "-5m"},
+				downloader,
+				refreshTokenFetcher,
+				logger,
+			)
+		}).To(Panic())
+		Expect(logger.fatalfMessage).To(Equal("--interval, --jitter and --max-backoff must not be negative."))
+	})
+
 	It("fatally logs if space-drain with same name already exists", func() {
 		cli.getAppError = nil
 		Expect(func() {
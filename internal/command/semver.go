@@ -0,0 +1,91 @@
+package command
+
+import (
+	"strconv"
+	"strings"
+)
+
+// semver is a parsed semantic version. Build metadata is dropped since it
+// does not affect precedence.
+type semver struct {
+	numbers    [3]uint64
+	preRelease []string
+}
+
+// parseSemver parses tags such as v1.2.3, 1.2.3-rc.1 or v0.5. Missing minor
+// and patch numbers are treated as 0. It reports false for anything else.
+func parseSemver(tag string) (semver, bool) {
+	var v semver
+
+	s := strings.TrimPrefix(tag, "v")
+	if i := strings.Index(s, "+"); i >= 0 {
+		s = s[:i]
+	}
+
+	if i := strings.Index(s, "-"); i >= 0 {
+		v.preRelease = strings.Split(s[i+1:], ".")
+		s = s[:i]
+
+		for _, id := range v.preRelease {
+			if id == "" {
+				return semver{}, false
+			}
+		}
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) > len(v.numbers) {
+		return semver{}, false
+	}
+
+	for i, p := range parts {
+		n, err := strconv.ParseUint(p, 10, 64)
+		if err != nil {
+			return semver{}, false
+		}
+		v.numbers[i] = n
+	}
+
+	return v, true
+}
+
+// less reports whether v has a lower precedence than o.
+func (v semver) less(o semver) bool {
+	for i := range v.numbers {
+		if v.numbers[i] != o.numbers[i] {
+			return v.numbers[i] < o.numbers[i]
+		}
+	}
+
+	// A pre-release has a lower precedence than its release.
+	if len(v.preRelease) == 0 || len(o.preRelease) == 0 {
+		return len(v.preRelease) > len(o.preRelease)
+	}
+
+	for i := 0; i < len(v.preRelease) && i < len(o.preRelease); i++ {
+		a, b := v.preRelease[i], o.preRelease[i]
+		if a == b {
+			continue
+		}
+
+		na, aErr := strconv.ParseUint(a, 10, 64)
+		nb, bErr := strconv.ParseUint(b, 10, 64)
+		switch {
+		case aErr == nil && bErr == nil:
+			return na < nb
+		case aErr == nil:
+			// Numeric identifiers have a lower precedence.
+			return true
+		case bErr == nil:
+			return false
+		default:
+			return a < b
+		}
+	}
+
+	return len(v.preRelease) < len(o.preRelease)
+}
+
+func (v semver) isPreRelease() bool {
+	return len(v.preRelease) > 0
+}
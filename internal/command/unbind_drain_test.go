@@ -0,0 +1,89 @@
+package command_test
+
+import (
+	"errors"
+
+	"code.cloudfoundry.org/cf-drain-cli/internal/command"
+	"code.cloudfoundry.org/cf-drain-cli/internal/drain"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("UnbindDrain", func() {
+	var (
+		logger       *stubLogger
+		cli          *stubCliConnection
+		drainFetcher *stubDrainFetcher
+	)
+
+	BeforeEach(func() {
+		logger = &stubLogger{}
+		cli = newStubCliConnection()
+		cli.currentSpaceGuid = "space-guid"
+		drainFetcher = newStubDrainFetcher()
+		drainFetcher.drains = []drain.Drain{
+			{Name: "drain-name"},
+		}
+	})
+
+	It("calls unbind-service with the given app name and service", func() {
+		args := []string{"app-name", "drain-name"}
+
+		command.UnbindDrain(cli, drainFetcher, args, logger)
+
+		Expect(drainFetcher.spaceGuids).To(Equal([]string{"space-guid"}))
+		Expect(cli.cliCommandArgs).To(Equal([][]string{
+			{"unbind-service", "app-name", "drain-name"},
+		}))
+	})
+
+	It("fatally logs if it fails to unbind the service", func() {
+		cli.unbindServiceError = errors.New("unable to unbind")
+		args := []string{"app-name", "drain-name"}
+
+		Expect(func() {
+			command.UnbindDrain(cli, drainFetcher, args, logger)
+		}).To(Panic())
+		Expect(logger.fatalfMessage).To(Equal("unable to unbind"))
+	})
+
+	It("expects to receive 2 arguments", func() {
+		args := []string{"app-name", "drain-name", "extra"}
+
+		Expect(func() {
+			command.UnbindDrain(cli, drainFetcher, args, logger)
+		}).To(Panic())
+		Expect(logger.fatalfMessage).To(Equal("Invalid arguments, expected 2, got 3."))
+	})
+
+	It("fatally logs if the service is not a drain", func() {
+		args := []string{"app-name", "my-database"}
+
+		Expect(func() {
+			command.UnbindDrain(cli, drainFetcher, args, logger)
+		}).To(Panic())
+		Expect(logger.fatalfMessage).To(Equal("my-database is not a valid drain."))
+		Expect(cli.cliCommandArgs).To(BeEmpty())
+	})
+
+	It("fatally logs if it fails to get existing drains", func() {
+		drainFetcher.err = errors.New("Failed to fetch drains.")
+		args := []string{"app-name", "drain-name"}
+
+		Expect(func() {
+			command.UnbindDrain(cli, drainFetcher, args, logger)
+		}).To(Panic())
+		Expect(logger.fatalfMessage).To(Equal("Failed to fetch drains."))
+	})
+
+	It("fatally logs if it fails to get space guid", func() {
+		cli.currentSpaceError = errors.New("Failed to get space.")
+		args := []string{"app-name", "drain-name"}
+
+		Expect(func() {
+			command.UnbindDrain(cli, drainFetcher, args, logger)
+		}).To(Panic())
+		Expect(logger.fatalfMessage).To(Equal("Failed to get space."))
+	})
+})
@@ -0,0 +1,90 @@
+package command
+
+import (
+	"net/url"
+
+	"code.cloudfoundry.org/cli/plugin"
+	flags "github.com/jessevdk/go-flags"
+)
+
+type updateDrainOpts struct {
+	DrainURL  string `long:"url"`
+	DrainType string `long:"type"`
+}
+
+// UpdateDrain changes the URL or type of an existing drain. The user
+// provided service is updated in place so every app stays bound to it.
+func UpdateDrain(
+	cli plugin.CliConnection,
+	args []string,
+	log Logger,
+	df DrainFetcher,
+) {
+	opts := updateDrainOpts{}
+
+	parser := flags.NewParser(&opts, flags.HelpFlag|flags.PassDoubleDash)
+	args, err := parser.ParseArgs(args)
+	if err != nil {
+		log.Fatalf("%s", err)
+	}
+
+	if len(args) != 1 {
+		log.Fatalf("Invalid arguments, expected 1, got %d.", len(args))
+	}
+
+	drainName := args[0]
+
+	if opts.DrainURL == "" && opts.DrainType == "" {
+		log.Fatalf("At least one of --url and --type must be given.")
+	}
+
+	if opts.DrainType != "" && !validDrainType(opts.DrainType) {
+		log.Fatalf("Invalid type: %s", opts.DrainType)
+	}
+
+	space := currentSpace(cli, log)
+
+	drains, err := df.Drains(space.Guid)
+	if err != nil {
+		log.Fatalf("Failed to fetch drains: %s", err)
+	}
+
+	d, ok := findDrain(drains, drainName)
+	if !ok {
+		log.Fatalf("%s is not a valid drain.", drainName)
+	}
+
+	drainURL := d.DrainURL
+	if opts.DrainURL != "" {
+		drainURL = opts.DrainURL
+	}
+
+	u, err := url.Parse(drainURL)
+	if err != nil {
+		log.Fatalf("Invalid syslog drain URL: %s", err)
+	}
+
+	// A new URL keeps the drain's current type unless the URL or --type
+	// gives one.
+	drainType := opts.DrainType
+	if drainType == "" && u.Query().Get("drain-type") == "" {
+		current, err := url.Parse(d.DrainURL)
+		if err == nil {
+			drainType = current.Query().Get("drain-type")
+		}
+	}
+
+	if drainType != "" {
+		setDrainType(u, drainType)
+	}
+
+	if u.String() == d.DrainURL {
+		log.Printf("Drain %s is already up to date.", drainName)
+		return
+	}
+
+	_, err = cli.CliCommand("update-user-provided-service", drainName, "-l", u.String())
+	if err != nil {
+		log.Fatalf("%s", err)
+	}
+}
@@ -0,0 +1,125 @@
+package command_test
+
+import (
+	"errors"
+
+	"code.cloudfoundry.org/cf-drain-cli/internal/command"
+	"code.cloudfoundry.org/cf-drain-cli/internal/drain"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("UpdateDrain", func() {
+	var (
+		logger       *stubLogger
+		cli          *stubCliConnection
+		drainFetcher *stubDrainFetcher
+	)
+
+	BeforeEach(func() {
+		logger = &stubLogger{}
+		cli = newStubCliConnection()
+		cli.currentSpaceGuid = "space-guid"
+		drainFetcher = newStubDrainFetcher()
+		drainFetcher.drains = []drain.Drain{
+			{
+				Name:     "drain-name",
+				DrainURL: "syslog://old.example.com?drain-type=metrics",
+				Apps:     []string{"app-1"},
+			},
+		}
+	})
+
+	It("updates the drain URL in place and keeps its type", func() {
+		args := []string{"drain-name", "--url", "syslog://new.example.com"}
+
+		command.UpdateDrain(cli, args, logger, drainFetcher)
+
+		Expect(drainFetcher.spaceGuids).To(Equal([]string{"space-guid"}))
+		Expect(cli.cliCommandArgs).To(Equal([][]string{
+			{"update-user-provided-service", "drain-name", "-l", "syslog://new.example.com?drain-type=metrics"},
+		}))
+	})
+
+	It("updates the drain type", func() {
+		args := []string{"drain-name", "--type", "all"}
+
+		command.UpdateDrain(cli, args, logger, drainFetcher)
+
+		Expect(cli.cliCommandArgs).To(Equal([][]string{
+			{"update-user-provided-service", "drain-name", "-l", "syslog://old.example.com?drain-type=all"},
+		}))
+	})
+
+	It("updates the drain URL and type", func() {
+		args := []string{"drain-name", "--url", "https://new.example.com?drain-type=logs", "--type", "all"}
+
+		command.UpdateDrain(cli, args, logger, drainFetcher)
+
+		Expect(cli.cliCommandArgs).To(Equal([][]string{
+			{"update-user-provided-service", "drain-name", "-l", "https://new.example.com?drain-type=all"},
+		}))
+	})
+
+	It("does nothing if the drain is up to date", func() {
+		args := []string{"drain-name", "--type", "metrics"}
+
+		command.UpdateDrain(cli, args, logger, drainFetcher)
+
+		Expect(cli.cliCommandArgs).To(BeEmpty())
+		Expect(logger.printfMessages).To(ContainElement("Drain drain-name is already up to date."))
+	})
+
+	It("fatally logs if neither --url nor --type are given", func() {
+		Expect(func() {
+			command.UpdateDrain(cli, []string{"drain-name"}, logger, drainFetcher)
+		}).To(Panic())
+		Expect(logger.fatalfMessage).To(Equal("At least one of --url and --type must be given."))
+	})
+
+	It("fatally logs for an invalid type", func() {
+		args := []string{"drain-name", "--type", "bogus"}
+
+		Expect(func() {
+			command.UpdateDrain(cli, args, logger, drainFetcher)
+		}).To(Panic())
+		Expect(logger.fatalfMessage).To(Equal("Invalid type: bogus"))
+	})
+
+	It("fatally logs if the drain does not exist", func() {
+		args := []string{"unknown-drain", "--type", "all"}
+
+		Expect(func() {
+			command.UpdateDrain(cli, args, logger, drainFetcher)
+		}).To(Panic())
+		Expect(logger.fatalfMessage).To(Equal("unknown-drain is not a valid drain."))
+	})
+
+	It("fatally logs if it fails to fetch drains", func() {
+		drainFetcher.err = errors.New("some-error")
+		args := []string{"drain-name", "--type", "all"}
+
+		Expect(func() {
+			command.UpdateDrain(cli, args, logger, drainFetcher)
+		}).To(Panic())
+		Expect(logger.fatalfMessage).To(Equal("Failed to fetch drains: some-error"))
+	})
+
+	It("fatally logs if the update fails", func() {
+		cli.updateServiceError = errors.New("unable to update")
+		args := []string{"drain-name", "--type", "all"}
+
+		Expect(func() {
+			command.UpdateDrain(cli, args, logger, drainFetcher)
+		}).To(Panic())
+		Expect(logger.fatalfMessage).To(Equal("unable to update"))
+	})
+
+	It("expects to receive 1 argument", func() {
+		Expect(func() {
+			command.UpdateDrain(cli, []string{"--type", "all"}, logger, drainFetcher)
+		}).To(Panic())
+		Expect(logger.fatalfMessage).To(Equal("Invalid arguments, expected 1, got 0."))
+	})
+})
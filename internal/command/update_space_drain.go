@@ -0,0 +1,113 @@
+package command
+
+import (
+	"net/url"
+
+	"code.cloudfoundry.org/cli/plugin"
+	flags "github.com/jessevdk/go-flags"
+)
+
+type updateSpaceDrainOpts struct {
+	DrainURL     string `long:"url"`
+	DrainType    string `long:"type"`
+	Path         string `long:"path"`
+	Upgrade      bool   `long:"upgrade"`
+	Version      string `long:"version"`
+	PreRelease   bool   `long:"pre-release"`
+	SkipChecksum bool   `long:"skip-checksum"`
+
+	ClientID     string `long:"client-id"`
+	ClientSecret string `long:"client-secret"`
+}
+
+// UpdateSpaceDrain changes the URL or type of an existing space drain and
+// optionally pushes a new binary. The app and its drain are kept, so apps
+// stay bound while the space drain restarts.
+func UpdateSpaceDrain(
+	cli plugin.CliConnection,
+	args []string,
+	d Downloader,
+	f RefreshTokenFetcher,
+	log Logger,
+) {
+	opts := updateSpaceDrainOpts{}
+
+	parser := flags.NewParser(&opts, flags.HelpFlag|flags.PassDoubleDash)
+	args, err := parser.ParseArgs(args)
+	if err != nil {
+		log.Fatalf("%s", err)
+	}
+
+	if len(args) != 1 {
+		log.Fatalf("Invalid arguments, expected 1, got %d.", len(args))
+	}
+
+	appName := args[0]
+
+	if opts.DrainType != "" && !validDrainType(opts.DrainType) {
+		log.Fatalf("Invalid type: %s", opts.DrainType)
+	}
+
+	if opts.Version != "" && opts.Path != "" {
+		log.Fatalf("--version and --path can not be used together.")
+	}
+
+	if (opts.ClientID == "") != (opts.ClientSecret == "") {
+		log.Fatalf("--client-id and --client-secret must be given together.")
+	}
+
+	if opts.DrainURL != "" {
+		_, err := url.Parse(opts.DrainURL)
+		if err != nil {
+			log.Fatalf("Invalid syslog drain URL: %s", err)
+		}
+	}
+
+	app, err := cli.GetApp(appName)
+	if err != nil {
+		log.Fatalf("%s", err)
+	}
+
+	envs := stringEnvs(app.EnvironmentVars)
+	if envs["DRAIN_SCOPE"] != "space" {
+		log.Fatalf("%s is not a space drain.", appName)
+	}
+
+	if opts.Upgrade || opts.Version != "" || opts.Path != "" {
+		pushDrainApp(cli, appName, "space_drain", opts.Path, opts.Version, opts.PreRelease, opts.SkipChecksum, d, log)
+	}
+
+	// A space drain already using client credentials keeps them unless new
+	// ones are given.
+	pushOpts := pushSpaceDrainOpts{
+		DrainName:    envs["DRAIN_NAME"],
+		DrainURL:     envs["DRAIN_URL"],
+		DrainType:    envs["DRAIN_TYPE"],
+		ClientID:     envs["CLIENT_ID"],
+		ClientSecret: envs["CLIENT_SECRET"],
+	}
+	if opts.DrainURL != "" {
+		pushOpts.DrainURL = opts.DrainURL
+	}
+	if opts.DrainType != "" {
+		pushOpts.DrainType = opts.DrainType
+	}
+	if opts.ClientSecret != "" {
+		pushOpts.ClientID = opts.ClientID
+		pushOpts.ClientSecret = opts.ClientSecret
+	}
+
+	setDrainEnvs(cli, appName, envs["SPACE_ID"], nil, pushOpts, f, log)
+
+	if pushOpts.ClientSecret != "" && envs["REFRESH_TOKEN"] != "" {
+		_, err = cli.CliCommandWithoutTerminalOutput("unset-env", appName, "REFRESH_TOKEN")
+		if err != nil {
+			log.Fatalf("%s", err)
+		}
+	}
+
+	_, err = cli.CliCommand("restart", appName)
+	if err != nil {
+		log.Fatalf("%s", err)
+	}
+}
@@ -0,0 +1,303 @@
+package command_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/cf-drain-cli/internal/command"
+)
+
+var _ = Describe("UpdateSpaceDrain", func() {
+	var (
+		logger              *stubLogger
+		cli                 *stubCliConnection
+		downloader          *stubDownloader
+		refreshTokenFetcher *stubRefreshTokenFetcher
+	)
+
+	BeforeEach(func() {
+		logger = &stubLogger{}
+		cli = newStubCliConnection()
+		cli.apiEndpoint = "https://api.something.com"
+		cli.getAppGuid = "space-drain-guid"
+		cli.getAppEnvVars = map[string]interface{}{
+			"DRAIN_SCOPE":    "space",
+			"DRAIN_NAME":     "some-drain",
+			"DRAIN_URL":      "syslog://old-drain",
+			"DRAIN_TYPE":     "logs",
+			"SPACE_ID":       "space-guid",
+			"DRAIN_SELECTOR": "env=prod",
+		}
+
+		downloader = newStubDownloader()
+		downloader.path = "/downloaded/temp/dir/space_drain"
+
+		refreshTokenFetcher = newStubRefreshTokenFetcher()
+		refreshTokenFetcher.token = "some-refresh-token"
+	})
+
+	It("updates the env of the space drain and restarts it", func() {
+		command.UpdateSpaceDrain(
+			cli,
+			[]string{"some-drain", "--url", "syslog://new-drain", "--type", "all"},
+			downloader,
+			refreshTokenFetcher,
+			logger,
+		)
+
+		Expect(cli.getAppName).To(Equal("some-drain"))
+		Expect(cli.cliCommandWithoutTerminalOutputArgs).To(ConsistOf(
+			[]string{"set-env", "some-drain", "SPACE_ID", "space-guid"},
+			[]string{"set-env", "some-drain", "DRAIN_NAME", "some-drain"},
+			[]string{"set-env", "some-drain", "DRAIN_URL", "syslog://new-drain"},
+			[]string{"set-env", "some-drain", "DRAIN_TYPE", "all"},
+			[]string{"set-env", "some-drain", "API_ADDR", "https://api.something.com"},
+			[]string{"set-env", "some-drain", "UAA_ADDR", "https://uaa.something.com"},
+			[]string{"set-env", "some-drain", "CLIENT_ID", "cf"},
+			[]string{"set-env", "some-drain", "REFRESH_TOKEN", "some-refresh-token"},
+			[]string{"set-env", "some-drain", "SKIP_CERT_VERIFY", "false"},
+			[]string{"set-env", "some-drain", "DRAIN_SCOPE", "space"},
+		))
+		Expect(cli.cliCommandArgs).To(Equal([][]string{
+			{"restart", "some-drain"},
+		}))
+		Expect(downloader.assetName).To(BeEmpty())
+	})
+
+	It("keeps the current url and type if they are not given", func() {
+		command.UpdateSpaceDrain(
+			cli,
+			[]string{"some-drain"},
+			downloader,
+			refreshTokenFetcher,
+			logger,
+		)
+
+		Expect(cli.cliCommandWithoutTerminalOutputArgs).To(ContainElement(
+			[]string{"set-env", "some-drain", "DRAIN_URL", "syslog://old-drain"},
+		))
+		Expect(cli.cliCommandWithoutTerminalOutputArgs).To(ContainElement(
+			[]string{"set-env", "some-drain", "DRAIN_TYPE", "logs"},
+		))
+	})
+
+	It("switches the space drain to client credentials", func() {
+		cli.getAppEnvVars["CLIENT_ID"] = "cf"
+		cli.getAppEnvVars["REFRESH_TOKEN"] = "old-refresh-token"
+
+		command.UpdateSpaceDrain(
+			cli,
+			[]string{"some-drain", "--client-id", "some-client", "--client-secret", "some-secret"},
+			downloader,
+			refreshTokenFetcher,
+			logger,
+		)
+
+		Expect(cli.cliCommandWithoutTerminalOutputArgs).To(ContainElement(
+			[]string{"set-env", "some-drain", "CLIENT_ID", "some-client"},
+		))
+		Expect(cli.cliCommandWithoutTerminalOutputArgs).To(ContainElement(
+			[]string{"set-env", "some-drain", "CLIENT_SECRET", "some-secret"},
+		))
+		Expect(cli.cliCommandWithoutTerminalOutputArgs).To(ContainElement(
+			[]string{"unset-env", "some-drain", "REFRESH_TOKEN"},
+		))
+		for _, args := range cli.cliCommandWithoutTerminalOutputArgs {
+			if args[0] == "set-env" {
+				Expect(args[2]).ToNot(Equal("REFRESH_TOKEN"))
+			}
+		}
+	})
+
+	It("keeps the client credentials of the space drain", func() {
+		cli.getAppEnvVars["CLIENT_ID"] = "some-client"
+		cli.getAppEnvVars["CLIENT_SECRET"] = "some-secret"
+		refreshTokenFetcher.err = errors.New("no token")
+
+		command.UpdateSpaceDrain(
+			cli,
+			[]string{"some-drain"},
+			downloader,
+			refreshTokenFetcher,
+			logger,
+		)
+
+		Expect(cli.cliCommandWithoutTerminalOutputArgs).To(ContainElement(
+			[]string{"set-env", "some-drain", "CLIENT_SECRET", "some-secret"},
+		))
+		Expect(cli.cliCommandWithoutTerminalOutputArgs).ToNot(ContainElement(
+			[]string{"unset-env", "some-drain", "REFRESH_TOKEN"},
+		))
+	})
+
+	It("fatally logs if only one of the client id and secret is given", func() {
+		Expect(func() {
+			command.UpdateSpaceDrain(cli, []string{"some-drain", "--client-secret", "some-secret"}, downloader, refreshTokenFetcher, logger)
+		}).To(Panic())
+		Expect(logger.fatalfMessage).To(Equal("--client-id and --client-secret must be given together."))
+	})
+
+	It("does not touch the drain or its bindings", func() {
+		command.UpdateSpaceDrain(
+			cli,
+			[]string{"some-drain", "--url", "syslog://new-drain"},
+			downloader,
+			refreshTokenFetcher,
+			logger,
+		)
+
+		for _, args := range cli.cliCommandArgs {
+			Expect(args[0]).ToNot(BeElementOf(
+				"delete", "delete-service", "unbind-service", "create-user-provided-service",
+			))
+		}
+	})
+
+	It("pushes the latest release before restarting when upgrading", func() {
+		command.UpdateSpaceDrain(
+			cli,
+			[]string{"some-drain", "--upgrade"},
+			downloader,
+			refreshTokenFetcher,
+			logger,
+		)
+
+		Expect(downloader.assetName).To(Equal("space_drain"))
+		Expect(cli.cliCommandArgs).To(Equal([][]string{
+			{
+				"push", "some-drain",
+				"-p", "/downloaded/temp/dir",
+				"-b", "binary_buildpack",
+				"-c", "./space_drain",
+				"-u", "http",
+				"--endpoint", "/health",
+				"--no-start",
+			},
+			{"restart", "some-drain"},
+		}))
+	})
+
+	It("pushes the given release", func() {
+		command.UpdateSpaceDrain(
+			cli,
+			[]string{"some-drain", "--version", "v1.2.3"},
+			downloader,
+			refreshTokenFetcher,
+			logger,
+		)
+
+		Expect(downloader.assetName).To(Equal("space_drain"))
+		Expect(downloader.version).To(Equal("v1.2.3"))
+		Expect(cli.cliCommandArgs[0][0]).To(Equal("push"))
+		Expect(logger.printfMessages).To(ContainElement("Downloading space drain v1.2.3 from github..."))
+	})
+
+	It("fatally logs if both a version and a path are given", func() {
+		Expect(func() {
+			command.UpdateSpaceDrain(
+				cli,
+				[]string{"some-drain", "--version", "v1.2.3", "--path", "some-path"},
+				downloader,
+				refreshTokenFetcher,
+				logger,
+			)
+		}).To(Panic())
+		Expect(logger.fatalfMessage).To(Equal("--version and --path can not be used together."))
+		Expect(cli.cliCommandArgs).To(BeEmpty())
+	})
+
+	It("skips the checksum of the upgrade if asked to", func() {
+		command.UpdateSpaceDrain(
+			cli,
+			[]string{"some-drain", "--upgrade", "--skip-checksum"},
+			downloader,
+			refreshTokenFetcher,
+			logger,
+		)
+
+		Expect(downloader.skipChecksum).To(BeTrue())
+	})
+
+	It("pushes the binary from the given path", func() {
+		command.UpdateSpaceDrain(
+			cli,
+			[]string{"some-drain", "--path", "some-temp-dir"},
+			downloader,
+			refreshTokenFetcher,
+			logger,
+		)
+
+		Expect(downloader.assetName).To(BeEmpty())
+		Expect(cli.cliCommandArgs[0]).To(Equal([]string{
+			"push", "some-drain",
+			"-p", "some-temp-dir",
+			"-b", "binary_buildpack",
+			"-c", "./space_drain",
+			"-u", "http",
+			"--endpoint", "/health",
+			"--no-start",
+		}))
+	})
+
+	It("fatally logs if the app is not a space drain", func() {
+		cli.getAppEnvVars = map[string]interface{}{}
+
+		Expect(func() {
+			command.UpdateSpaceDrain(cli, []string{"my-app"}, downloader, refreshTokenFetcher, logger)
+		}).To(Panic())
+		Expect(logger.fatalfMessage).To(Equal("my-app is not a space drain."))
+		Expect(cli.cliCommandArgs).To(BeEmpty())
+	})
+
+	It("fatally logs if the app can not be found", func() {
+		cli.getAppError = errors.New("App some-drain not found")
+
+		Expect(func() {
+			command.UpdateSpaceDrain(cli, []string{"some-drain"}, downloader, refreshTokenFetcher, logger)
+		}).To(Panic())
+		Expect(logger.fatalfMessage).To(Equal("App some-drain not found"))
+	})
+
+	It("fatally logs if the type is invalid", func() {
+		Expect(func() {
+			command.UpdateSpaceDrain(cli, []string{"some-drain", "--type", "bad"}, downloader, refreshTokenFetcher, logger)
+		}).To(Panic())
+		Expect(logger.fatalfMessage).To(Equal("Invalid type: bad"))
+	})
+
+	It("fatally logs if pushing fails", func() {
+		cli.pushAppError = errors.New("push failed")
+
+		Expect(func() {
+			command.UpdateSpaceDrain(cli, []string{"some-drain", "--upgrade"}, downloader, refreshTokenFetcher, logger)
+		}).To(Panic())
+		Expect(logger.fatalfMessage).To(Equal("push failed"))
+	})
+
+	It("fatally logs if fetching the refresh token fails", func() {
+		refreshTokenFetcher.err = errors.New("no token")
+
+		Expect(func() {
+			command.UpdateSpaceDrain(cli, []string{"some-drain"}, downloader, refreshTokenFetcher, logger)
+		}).To(Panic())
+		Expect(logger.fatalfMessage).To(Equal("no token"))
+	})
+
+	It("fatally logs if restarting fails", func() {
+		cli.restartAppError = errors.New("restart failed")
+
+		Expect(func() {
+			command.UpdateSpaceDrain(cli, []string{"some-drain"}, downloader, refreshTokenFetcher, logger)
+		}).To(Panic())
+		Expect(logger.fatalfMessage).To(Equal("restart failed"))
+	})
+
+	It("expects to receive 1 argument", func() {
+		Expect(func() {
+			command.UpdateSpaceDrain(cli, []string{}, downloader, refreshTokenFetcher, logger)
+		}).To(Panic())
+		Expect(logger.fatalfMessage).To(Equal("Invalid arguments, expected 1, got 0."))
+	})
+})
@@ -0,0 +1,27 @@
+package drain
+
+import "strings"
+
+// ExcludeAnnotation is the app annotation that keeps an app out of space
+// drains. Its value is a comma separated list of space drain names, or * for
+// every space drain. The DRAIN_EXCLUDE environment variable works the same
+// way for apps on foundations without v3 metadata.
+const ExcludeAnnotation = "drains.cloudfoundry.org/exclude"
+
+// IsExcluded reports whether an app with the given annotations and
+// environment variables opted out of the named space drain.
+func IsExcluded(annotations, envs map[string]string, spaceDrainName string) bool {
+	return excludes(annotations[ExcludeAnnotation], spaceDrainName) ||
+		excludes(envs["DRAIN_EXCLUDE"], spaceDrainName)
+}
+
+func excludes(value, spaceDrainName string) bool {
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == spaceDrainName || name == "*" {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,24 @@
+package drain_test
+
+import (
+	"code.cloudfoundry.org/cf-drain-cli/internal/drain"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("IsExcluded", func() {
+	DescribeTable("apps that opted out",
+		func(annotations, envs map[string]string, excluded bool) {
+			Expect(drain.IsExcluded(annotations, envs, "space-drain")).To(Equal(excluded))
+		},
+		Entry("no annotation or env", nil, nil, false),
+		Entry("annotation", map[string]string{drain.ExcludeAnnotation: "space-drain"}, nil, true),
+		Entry("annotation list", map[string]string{drain.ExcludeAnnotation: "other, space-drain"}, nil, true),
+		Entry("annotation wildcard", map[string]string{drain.ExcludeAnnotation: "*"}, nil, true),
+		Entry("annotation for another drain", map[string]string{drain.ExcludeAnnotation: "other"}, nil, false),
+		Entry("env", nil, map[string]string{"DRAIN_EXCLUDE": "space-drain"}, true),
+		Entry("env for another drain", nil, map[string]string{"DRAIN_EXCLUDE": "other"}, false),
+	)
+})
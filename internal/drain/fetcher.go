@@ -0,0 +1,168 @@
+package drain
+
+import (
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// pageInfo is the pagination state read from a single page of results.
+type pageInfo struct {
+	next       string
+	totalPages int
+}
+
+// fetchPages requests path and every page that follows it. decode is called
+// once for each page, in page order. When more than one worker is configured
+// and the first page reports the total number of pages, the remaining pages
+// are requested concurrently.
+func (l *ServiceDrainLister) fetchPages(path string, decode func([]byte) (pageInfo, error)) error {
+	resp, err := l.curl(path)
+	if err != nil {
+		return err
+	}
+
+	info, err := decode(resp)
+	if err != nil {
+		return err
+	}
+
+	if l.workers > 1 && info.totalPages > 1 {
+		pages := make([][]byte, info.totalPages-1)
+		err := l.parallel(len(pages), func(i int) error {
+			page, err := l.curl(withPage(path, i+2))
+			pages[i] = page
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, page := range pages {
+			if _, err := decode(page); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	for info.next != "" {
+		resp, err := l.curl(info.next)
+		if err != nil {
+			return err
+		}
+
+		info, err = decode(resp)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// curl makes a single request. Every request holds a slot of the lister's
+// semaphore, so no more than l.workers requests are in flight however
+// deeply parallel calls are nested.
+func (l *ServiceDrainLister) curl(path string) ([]byte, error) {
+	if l.sem != nil {
+		l.sem <- struct{}{}
+		defer func() { <-l.sem }()
+	}
+
+	l.limiter.wait()
+	return l.c.Curl(path, "GET", "")
+}
+
+// parallel calls f for every index in [0, n) using at most l.workers
+// goroutines. Requests made by f are bounded by the lister's semaphore, not
+// by the number of goroutines. If any call fails, the error with the lowest
+// index is returned so that failures are reported deterministically.
+func (l *ServiceDrainLister) parallel(n int, f func(i int) error) error {
+	if l.workers <= 1 {
+		for i := 0; i < n; i++ {
+			if err := f(i); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	errs := make([]error, n)
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < l.workers && w < n; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				errs[i] = f(i)
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func withPage(path string, page int) string {
+	u, err := url.Parse(path)
+	if err != nil {
+		return path
+	}
+
+	q := u.Query()
+	q.Set("page", strconv.Itoa(page))
+	u.RawQuery = q.Encode()
+
+	return u.String()
+}
+
+// rateLimiter spaces requests out so that no more than the configured number
+// of requests per second are started. A nil rateLimiter does not limit.
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func newRateLimiter(requestsPerSecond int) *rateLimiter {
+	if requestsPerSecond <= 0 {
+		return nil
+	}
+
+	return &rateLimiter{
+		interval: time.Second / time.Duration(requestsPerSecond),
+	}
+}
+
+func (r *rateLimiter) wait() {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	now := time.Now()
+	if r.next.Before(now) {
+		r.next = now
+	}
+	wait := r.next.Sub(now)
+	r.next = r.next.Add(r.interval)
+	r.mu.Unlock()
+
+	time.Sleep(wait)
+}
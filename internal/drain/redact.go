@@ -0,0 +1,55 @@
+package drain
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// RedactURL replaces the user info of a drain URL and the values of its
+// query parameters, other than drain-type, with the string replacement
+// returns for each of them. replacement is called with "username",
+// "password" or the name of the query parameter. If replacement is nil
+// every secret becomes <redacted>.
+func RedactURL(u *url.URL, replacement func(part string) string) string {
+	if replacement == nil {
+		replacement = func(string) string { return "<redacted>" }
+	}
+
+	// The replacements are not valid in a URL, so sentinels stand in for
+	// them until the URL is encoded.
+	replacements := make(map[string]string)
+	sentinel := func(part string) string {
+		s := fmt.Sprintf("---REDACTED-%d---", len(replacements))
+		replacements[s] = replacement(part)
+		return s
+	}
+
+	redacted := *u
+	if u.User != nil {
+		username := sentinel("username")
+		if _, ok := u.User.Password(); ok {
+			redacted.User = url.UserPassword(username, sentinel("password"))
+		} else {
+			redacted.User = url.User(username)
+		}
+	}
+
+	query := u.Query()
+	for k, v := range query {
+		if k == "drain-type" {
+			continue
+		}
+		for i := range v {
+			v[i] = sentinel(k)
+		}
+	}
+	redacted.RawQuery = query.Encode()
+
+	s := redacted.String()
+	for sentinel, r := range replacements {
+		s = strings.Replace(s, sentinel, r, -1)
+	}
+
+	return s
+}
@@ -12,12 +12,20 @@ import (
 type ServiceDrainLister struct {
 	c                 cloudcontroller.Curler
 	appNameBatchLimit int
+	bindingBatchLimit int
+	apiVersion        cloudcontroller.APIVersion
+	workers           int
+	sem               chan struct{}
+	limiter           *rateLimiter
 }
 
 func NewServiceDrainLister(c cloudcontroller.Curler, opts ...ServiceDrainListerOption) *ServiceDrainLister {
 	dl := &ServiceDrainLister{
 		c:                 c,
 		appNameBatchLimit: 100,
+		bindingBatchLimit: 50,
+		apiVersion:        cloudcontroller.V3,
+		workers:           1,
 	}
 
 	for _, o := range opts {
@@ -35,6 +43,37 @@ func WithServiceDrainAppBatchLimit(limit int) ServiceDrainListerOption {
 	}
 }
 
+// WithServiceDrainBindingBatchLimit sets how many service instance GUIDs are
+// used to filter a single request for bindings.
+func WithServiceDrainBindingBatchLimit(limit int) ServiceDrainListerOption {
+	return func(l *ServiceDrainLister) {
+		l.bindingBatchLimit = limit
+	}
+}
+
+// WithServiceDrainAPIVersion sets the Cloud Controller API used to list
+// service instances and their bindings. It defaults to V3.
+func WithServiceDrainAPIVersion(v cloudcontroller.APIVersion) ServiceDrainListerOption {
+	return func(l *ServiceDrainLister) {
+		l.apiVersion = v
+	}
+}
+
+// WithServiceDrainConcurrency sets how many Cloud Controller requests may be
+// in flight at once and how many may be started each second. A
+// requestsPerSecond of zero does not limit the request rate. By default
+// requests are made one at a time without a rate limit.
+func WithServiceDrainConcurrency(workers, requestsPerSecond int) ServiceDrainListerOption {
+	return func(l *ServiceDrainLister) {
+		if workers < 1 {
+			workers = 1
+		}
+		l.workers = workers
+		l.sem = make(chan struct{}, workers)
+		l.limiter = newRateLimiter(requestsPerSecond)
+	}
+}
+
 type Drain struct {
 	Name     string
 	Guid     string
@@ -45,37 +84,46 @@ type Drain struct {
 }
 
 func (l *ServiceDrainLister) Drains(spaceGuid string) ([]Drain, error) {
-	var url string
-	url = fmt.Sprintf("/v2/user_provided_service_instances?q=space_guid:%s", spaceGuid)
-	instances, err := l.fetchServiceInstances(url)
+	instances, err := l.fetchServiceInstances(spaceGuid)
 	if err != nil {
 		return nil, err
 	}
 
-	var appGuids []string
-	var drains []Drain
+	// Skip services that are not drains before fetching anything else for
+	// them.
+	var drainInstances []serviceInstance
+	var instanceGuids []string
 	for _, s := range instances {
-		if s.Entity.SyslogDrainURL == "" {
+		if s.SyslogDrainURL == "" {
 			continue
 		}
 
-		apps, err := l.fetchApps(s.Entity.ServiceBindingsURL)
-		if err != nil {
-			return nil, err
-		}
+		drainInstances = append(drainInstances, s)
+		instanceGuids = append(instanceGuids, s.Guid)
+	}
+
+	bindings, err := l.fetchBatchBindings(instanceGuids)
+	if err != nil {
+		return nil, err
+	}
+
+	var appGuids []string
+	var drains []Drain
+	for _, s := range drainInstances {
+		apps := bindings[s.Guid]
 		appGuids = append(appGuids, apps...)
 
-		drainType, err := l.TypeFromDrainURL(s.Entity.SyslogDrainURL)
+		drainType, err := l.TypeFromDrainURL(s.SyslogDrainURL)
 		if err != nil {
 			return nil, err
 		}
 
 		drain, err := l.buildDrain(
 			apps,
-			s.Entity.Name,
-			s.MetaData.Guid,
+			s.Name,
+			s.Guid,
 			drainType,
-			s.Entity.SyslogDrainURL,
+			s.SyslogDrainURL,
 		)
 		if err != nil {
 			return nil, err
@@ -105,67 +153,183 @@ func (l *ServiceDrainLister) Drains(spaceGuid string) ([]Drain, error) {
 	return namedDrains, nil
 }
 
-func (l *ServiceDrainLister) fetchServiceInstances(url string) ([]userProvidedServiceInstance, error) {
-	instances := []userProvidedServiceInstance{}
-	for url != "" {
-		resp, err := l.c.Curl(url, "GET", "")
-		if err != nil {
-			return nil, err
+// serviceInstance is a user provided service instance from either the v2 or
+// the v3 API.
+type serviceInstance struct {
+	Guid           string
+	Name           string
+	SyslogDrainURL string
+}
+
+func (l *ServiceDrainLister) fetchServiceInstances(spaceGuid string) ([]serviceInstance, error) {
+	if l.apiVersion == cloudcontroller.V3 {
+		params := url.Values{
+			"type":        {"user-provided"},
+			"space_guids": {spaceGuid},
 		}
+		return l.fetchV3ServiceInstances("/v3/service_instances?" + params.Encode())
+	}
+
+	url := fmt.Sprintf("/v2/user_provided_service_instances?q=space_guid:%s", spaceGuid)
+	return l.fetchV2ServiceInstances(url)
+}
 
+func (l *ServiceDrainLister) fetchV2ServiceInstances(url string) ([]serviceInstance, error) {
+	instances := []serviceInstance{}
+	err := l.fetchPages(url, func(resp []byte) (pageInfo, error) {
 		var services userProvidedServiceInstancesResponse
-		err = json.Unmarshal(resp, &services)
+		err := json.Unmarshal(resp, &services)
 		if err != nil {
-			return nil, err
+			return pageInfo{}, err
 		}
 
-		instances = append(instances, services.Resources...)
+		for _, s := range services.Resources {
+			instances = append(instances, serviceInstance{
+				Guid:           s.MetaData.Guid,
+				Name:           s.Entity.Name,
+				SyslogDrainURL: s.Entity.SyslogDrainURL,
+			})
+		}
 
-		url = services.NextURL
+		return pageInfo{services.NextURL, services.TotalPages}, nil
+	})
+	if err != nil {
+		return nil, err
 	}
+
 	return instances, nil
 }
 
-func (l *ServiceDrainLister) fetchApps(url string) ([]string, error) {
-	var apps []string
-	for url != "" {
-		resp, err := l.c.Curl(url, "GET", "")
+func (l *ServiceDrainLister) fetchV3ServiceInstances(path string) ([]serviceInstance, error) {
+	instances := []serviceInstance{}
+	err := l.fetchPages(path, func(resp []byte) (pageInfo, error) {
+		var services v3ServiceInstancesResponse
+		err := json.Unmarshal(resp, &services)
 		if err != nil {
-			return nil, err
+			return pageInfo{}, err
+		}
+
+		for _, s := range services.Resources {
+			instances = append(instances, serviceInstance{
+				Guid:           s.Guid,
+				Name:           s.Name,
+				SyslogDrainURL: s.SyslogDrainURL,
+			})
+		}
+
+		return services.Pagination.pageInfo(), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return instances, nil
+}
+
+// fetchBatchBindings returns the bound app GUIDs for each of the given
+// service instances. Bindings are requested for several service instances at
+// a time instead of once per instance.
+func (l *ServiceDrainLister) fetchBatchBindings(instanceGuids []string) (map[string][]string, error) {
+	batches := batchStrings(instanceGuids, l.bindingBatchLimit)
+	results := make([]map[string][]string, len(batches))
+	err := l.parallel(len(batches), func(i int) error {
+		bindings, err := l.fetchBindings(batches[i])
+		results[i] = bindings
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	allBindings := make(map[string][]string)
+	for _, bindings := range results {
+		for k, v := range bindings {
+			allBindings[k] = append(allBindings[k], v...)
 		}
+	}
+
+	return allBindings, nil
+}
 
+func (l *ServiceDrainLister) fetchBindings(instanceGuids []string) (map[string][]string, error) {
+	if len(instanceGuids) == 0 {
+		return nil, nil
+	}
+
+	if l.apiVersion == cloudcontroller.V3 {
+		params := url.Values{
+			"type":                   {"app"},
+			"service_instance_guids": {strings.Join(instanceGuids, ",")},
+		}
+		return l.fetchV3Bindings("/v3/service_credential_bindings?" + params.Encode())
+	}
+
+	params := url.Values{
+		"q": {"service_instance_guid IN " + strings.Join(instanceGuids, ",")},
+	}
+	return l.fetchV2Bindings("/v2/service_bindings?" + params.Encode())
+}
+
+func (l *ServiceDrainLister) fetchV2Bindings(url string) (map[string][]string, error) {
+	apps := make(map[string][]string)
+	err := l.fetchPages(url, func(resp []byte) (pageInfo, error) {
 		var serviceBindingsResponse serviceBindingsResponse
-		err = json.Unmarshal(resp, &serviceBindingsResponse)
+		err := json.Unmarshal(resp, &serviceBindingsResponse)
 		if err != nil {
-			return nil, err
+			return pageInfo{}, err
 		}
 
 		for _, r := range serviceBindingsResponse.Resources {
-			apps = append(apps, r.Entity.AppGuid)
+			instanceGuid := r.Entity.ServiceInstanceGuid
+			apps[instanceGuid] = append(apps[instanceGuid], r.Entity.AppGuid)
 		}
 
-		url = serviceBindingsResponse.NextURL
+		return pageInfo{serviceBindingsResponse.NextURL, serviceBindingsResponse.TotalPages}, nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return apps, nil
 }
 
-func (l *ServiceDrainLister) fetchBatchAppNames(guids []string) (map[string]string, error) {
-	guids = uniqueStringSlice(guids)
-
-	allAppNames := make(map[string]string)
-	for i := 0; i < len(guids); i += l.appNameBatchLimit {
-		end := i + l.appNameBatchLimit
-
-		if end > len(guids) {
-			end = len(guids)
+func (l *ServiceDrainLister) fetchV3Bindings(path string) (map[string][]string, error) {
+	apps := make(map[string][]string)
+	err := l.fetchPages(path, func(resp []byte) (pageInfo, error) {
+		var bindings v3ServiceCredentialBindingsResponse
+		err := json.Unmarshal(resp, &bindings)
+		if err != nil {
+			return pageInfo{}, err
 		}
 
-		appNames, err := l.fetchAppNames(guids[i:end])
-		if err != nil {
-			return nil, err
+		for _, r := range bindings.Resources {
+			instanceGuid := r.Relationships.ServiceInstance.Data.Guid
+			apps[instanceGuid] = append(apps[instanceGuid], r.Relationships.App.Data.Guid)
 		}
 
+		return bindings.Pagination.pageInfo(), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return apps, nil
+}
+
+func (l *ServiceDrainLister) fetchBatchAppNames(guids []string) (map[string]string, error) {
+	batches := batchStrings(uniqueStringSlice(guids), l.appNameBatchLimit)
+	results := make([]map[string]string, len(batches))
+	err := l.parallel(len(batches), func(i int) error {
+		appNames, err := l.fetchAppNames(batches[i])
+		results[i] = appNames
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	allAppNames := make(map[string]string)
+	for _, appNames := range results {
 		for k, v := range appNames {
 			allAppNames[k] = v
 		}
@@ -183,24 +347,22 @@ func (l *ServiceDrainLister) fetchAppNames(guids []string) (map[string]string, e
 		"guids": {strings.Join(guids, ",")},
 	}
 
-	url := "/v3/apps?" + params.Encode()
 	apps := make(map[string]string)
-	for url != "" {
-		resp, err := l.c.Curl(url, "GET", "")
-		if err != nil {
-			return nil, err
-		}
-
+	err := l.fetchPages("/v3/apps?"+params.Encode(), func(resp []byte) (pageInfo, error) {
 		var appsResp appsResponse
-		err = json.Unmarshal(resp, &appsResp)
+		err := json.Unmarshal(resp, &appsResp)
 		if err != nil {
-			return nil, err
+			return pageInfo{}, err
 		}
 
 		for _, a := range appsResp.Apps {
 			apps[a.Guid] = a.Name
 		}
-		url = appsResp.Pagination.Next
+
+		return appsResp.Pagination.pageInfo(), nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return apps, nil
@@ -231,8 +393,9 @@ func (l *ServiceDrainLister) buildDrain(apps []string, name, guid, drainType, dr
 }
 
 type userProvidedServiceInstancesResponse struct {
-	NextURL   string                        `json:"next_url"`
-	Resources []userProvidedServiceInstance `json:"resources"`
+	NextURL    string                        `json:"next_url"`
+	TotalPages int                           `json:"total_pages"`
+	Resources  []userProvidedServiceInstance `json:"resources"`
 }
 
 type userProvidedServiceInstance struct {
@@ -240,29 +403,63 @@ type userProvidedServiceInstance struct {
 		Guid string `json:"guid"`
 	} `json:"metadata"`
 	Entity struct {
-		Name               string `json:"name"`
-		ServiceBindingsURL string `json:"service_bindings_url"`
-		SyslogDrainURL     string `json:"syslog_drain_url"`
+		Name           string `json:"name"`
+		SyslogDrainURL string `json:"syslog_drain_url"`
 	} `json:"entity"`
 }
 
 type serviceBindingsResponse struct {
-	NextURL   string           `json:"next_url"`
-	Resources []serviceBinding `json:"resources"`
+	NextURL    string           `json:"next_url"`
+	TotalPages int              `json:"total_pages"`
+	Resources  []serviceBinding `json:"resources"`
 }
 
 type serviceBinding struct {
 	Entity struct {
-		AppGuid string `json:"app_guid"`
-		AppUrl  string `json:"app_url"`
+		AppGuid             string `json:"app_guid"`
+		ServiceInstanceGuid string `json:"service_instance_guid"`
 	} `json:"entity"`
 }
 
+type v3Pagination struct {
+	TotalPages int                  `json:"total_pages"`
+	Next       cloudcontroller.Link `json:"next"`
+}
+
+func (p v3Pagination) pageInfo() pageInfo {
+	return pageInfo{p.Next.Path(), p.TotalPages}
+}
+
+type v3ServiceInstancesResponse struct {
+	Pagination v3Pagination `json:"pagination"`
+	Resources  []struct {
+		Guid           string `json:"guid"`
+		Name           string `json:"name"`
+		SyslogDrainURL string `json:"syslog_drain_url"`
+	} `json:"resources"`
+}
+
+type v3ServiceCredentialBindingsResponse struct {
+	Pagination v3Pagination `json:"pagination"`
+	Resources  []struct {
+		Relationships struct {
+			App struct {
+				Data struct {
+					Guid string `json:"guid"`
+				} `json:"data"`
+			} `json:"app"`
+			ServiceInstance struct {
+				Data struct {
+					Guid string `json:"guid"`
+				} `json:"data"`
+			} `json:"service_instance"`
+		} `json:"relationships"`
+	} `json:"resources"`
+}
+
 type appsResponse struct {
-	Apps       []appData `json:"resources"`
-	Pagination struct {
-		Next string `json:"next"`
-	} `json:pagination`
+	Apps       []appData    `json:"resources"`
+	Pagination v3Pagination `json:"pagination"`
 }
 
 type appData struct {
@@ -270,6 +467,21 @@ type appData struct {
 	Guid string `json:"guid"`
 }
 
+func batchStrings(str []string, size int) [][]string {
+	var batches [][]string
+	for i := 0; i < len(str); i += size {
+		end := i + size
+
+		if end > len(str) {
+			end = len(str)
+		}
+
+		batches = append(batches, str[i:end])
+	}
+
+	return batches
+}
+
 func uniqueStringSlice(str []string) []string {
 	var results []string
 	for _, s := range str {
@@ -3,8 +3,14 @@ package drain_test
 import (
 	"errors"
 	"fmt"
+	"net/url"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
 
+	"code.cloudfoundry.org/cf-drain-cli/internal/cloudcontroller"
 	"code.cloudfoundry.org/cf-drain-cli/internal/drain"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -22,6 +28,7 @@ var _ = Describe("ListDrainsClient", func() {
 		c = drain.NewServiceDrainLister(
 			curler,
 			drain.WithServiceDrainAppBatchLimit(3),
+			drain.WithServiceDrainAPIVersion(cloudcontroller.V2),
 		)
 	})
 
@@ -54,7 +61,7 @@ var _ = Describe("ListDrainsClient", func() {
 			key = "/v2/user_provided_service_instances?q=space_guid:space-guid"
 			curler.resps[key] = serviceInstancesJSON2
 
-			key = "/v2/user_provided_service_instances/drain-2/service_bindings"
+			key = "/v2/service_bindings?q=service_instance_guid+IN+guid-2"
 			curler.resps[key] = serviceBindingsJSON3
 
 			key = "/v3/apps?guids=app-1,app-2,app-3"
@@ -81,7 +88,7 @@ var _ = Describe("ListDrainsClient", func() {
 				"/v3/apps?guids=app-4",
 			}))
 
-			// 8 => 2 service fetch + (2 app fetches) +  (4 app name fetches)
+			// 4 => 1 service fetch + 1 binding fetch + 2 app name fetches
 			Expect(curler.methods).To(ConsistOf("GET", "GET", "GET", "GET"))
 			Expect(curler.bodies).To(ConsistOf("", "", "", ""))
 		})
@@ -97,12 +104,10 @@ var _ = Describe("ListDrainsClient", func() {
 
 		Context("requesting service bindings succeeds", func() {
 			BeforeEach(func() {
-				key = "/v2/user_provided_service_instances/drain-1/service_bindings"
+				key = "/v2/service_bindings?q=service_instance_guid+IN+guid-1,guid-2"
 				curler.resps[key] = serviceBindingsJSON1page1
-				key = "/v2/user_provided_service_instances/drain-1/service_bindings&page:2"
+				key = "/v2/service_bindings?q=service_instance_guid+IN+guid-1,guid-2&page=2"
 				curler.resps[key] = serviceBindingsJSON1page2
-				key = "/v2/user_provided_service_instances/drain-2/service_bindings"
-				curler.resps[key] = serviceBindingsJSON2
 			})
 
 			Context("requesting app names succeeds", func() {
@@ -133,9 +138,30 @@ var _ = Describe("ListDrainsClient", func() {
 					Expect(d[1].Type).To(Equal("metrics"))
 					Expect(d[1].DrainURL).To(Equal("https://your-app2.cf-app.com?drain-type=metrics"))
 
-					// 7 => 2 service fetch + (2 app fetches) +  (3 app name fetches)
-					Expect(curler.methods).To(ConsistOf("GET", "GET", "GET", "GET", "GET", "GET", "GET"))
-					Expect(curler.bodies).To(ConsistOf("", "", "", "", "", "", ""))
+					// 6 => 2 service fetches + 2 binding fetches + 2 app name fetches
+					Expect(curler.methods).To(ConsistOf("GET", "GET", "GET", "GET", "GET", "GET"))
+					Expect(curler.bodies).To(ConsistOf("", "", "", "", "", ""))
+				})
+
+				It("requests bindings in batches of service instances", func() {
+					c = drain.NewServiceDrainLister(
+						curler,
+						drain.WithServiceDrainBindingBatchLimit(1),
+						drain.WithServiceDrainAPIVersion(cloudcontroller.V2),
+					)
+					key = "/v2/service_bindings?q=service_instance_guid+IN+guid-1"
+					curler.resps[key] = serviceBindingsJSON2
+					key = "/v2/service_bindings?q=service_instance_guid+IN+guid-2"
+					curler.resps[key] = serviceBindingsJSON4
+
+					d, err := c.Drains("space-guid")
+					Expect(err).ToNot(HaveOccurred())
+					Expect(d).To(HaveLen(2))
+					Expect(d[0].AppGuids).To(Equal([]string{"app-1"}))
+					Expect(d[1].AppGuids).To(Equal([]string{"app-2"}))
+
+					Expect(curler.URLs).To(ContainElement("/v2/service_bindings?q=service_instance_guid+IN+guid-1"))
+					Expect(curler.URLs).To(ContainElement("/v2/service_bindings?q=service_instance_guid+IN+guid-2"))
 				})
 			})
 
@@ -157,7 +183,7 @@ var _ = Describe("ListDrainsClient", func() {
 		})
 
 		It("returns the error if requesting the service bindings fails", func() {
-			key = "/v2/user_provided_service_instances/drain-1/service_bindings"
+			key = "/v2/service_bindings?q=service_instance_guid+IN+guid-1,guid-2"
 			curler.errs[key] = errors.New("some error")
 
 			_, err := c.Drains("space-guid")
@@ -165,7 +191,7 @@ var _ = Describe("ListDrainsClient", func() {
 		})
 
 		It("returns the error if unmarshalling the service bindings fails", func() {
-			key = "/v2/user_provided_service_instances/drain-1/service_bindings"
+			key = "/v2/service_bindings?q=service_instance_guid+IN+guid-1,guid-2"
 			curler.resps[key] = "no json"
 
 			_, err := c.Drains("space-guid")
@@ -189,6 +215,163 @@ var _ = Describe("ListDrainsClient", func() {
 		Expect(err).To(HaveOccurred())
 	})
 
+	Context("with the v3 API", func() {
+		BeforeEach(func() {
+			c = drain.NewServiceDrainLister(
+				curler,
+				drain.WithServiceDrainAppBatchLimit(3),
+			)
+
+			key = "/v3/service_instances?space_guids=space-guid&type=user-provided"
+			curler.resps[key] = v3ServiceInstancesJSONpage1
+			key = "/v3/service_instances?page=2&space_guids=space-guid&type=user-provided"
+			curler.resps[key] = v3ServiceInstancesJSONpage2
+
+			key = "/v3/service_credential_bindings?service_instance_guids=guid-1,guid-2&type=app"
+			curler.resps[key] = v3ServiceCredentialBindingsJSONpage1
+			key = "/v3/service_credential_bindings?page=2&service_instance_guids=guid-1,guid-2&type=app"
+			curler.resps[key] = v3ServiceCredentialBindingsJSONpage2
+
+			key = "/v3/apps?guids=app-1,app-2"
+			curler.resps[key] = appJSONpage1
+			key = "/v3/apps?guids=app-1,app-2&page=2"
+			curler.resps[key] = appJSONpage2
+		})
+
+		It("returns every drain", func() {
+			d, err := c.Drains("space-guid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(d).To(HaveLen(2))
+
+			Expect(d[0].Name).To(Equal("drain-1"))
+			Expect(d[0].Guid).To(Equal("guid-1"))
+			Expect(d[0].Apps).To(Equal([]string{"My App One", "My App Two"}))
+			Expect(d[0].AppGuids).To(Equal([]string{"app-1", "app-2"}))
+			Expect(d[0].Type).To(Equal("logs"))
+			Expect(d[0].DrainURL).To(Equal("syslog://your-app.cf-app.com"))
+
+			Expect(d[1].Name).To(Equal("drain-2"))
+			Expect(d[1].Guid).To(Equal("guid-2"))
+			Expect(d[1].Apps).To(Equal([]string{"My App One"}))
+			Expect(d[1].AppGuids).To(Equal([]string{"app-1"}))
+			Expect(d[1].Type).To(Equal("metrics"))
+			Expect(d[1].DrainURL).To(Equal("https://your-app2.cf-app.com?drain-type=metrics"))
+
+			Expect(curler.URLs).ToNot(ContainElement(HavePrefix("/v2")))
+			Expect(curler.URLs).ToNot(ContainElement(ContainSubstring("other-guid")))
+		})
+
+		Context("with concurrent requests", func() {
+			BeforeEach(func() {
+				c = drain.NewServiceDrainLister(
+					curler,
+					drain.WithServiceDrainAppBatchLimit(1),
+					drain.WithServiceDrainConcurrency(4, 0),
+				)
+
+				key = "/v3/apps?guids=app-1"
+				curler.resps[key] = appJSONcall3
+				key = "/v3/apps?guids=app-2"
+				curler.resps[key] = appJSONcall4
+			})
+
+			It("returns every drain in order", func() {
+				for i := 0; i < 10; i++ {
+					d, err := c.Drains("space-guid")
+					Expect(err).ToNot(HaveOccurred())
+					Expect(d).To(HaveLen(2))
+
+					Expect(d[0].Name).To(Equal("drain-1"))
+					Expect(d[0].Apps).To(Equal([]string{"My App One", "My App Two"}))
+					Expect(d[0].AppGuids).To(Equal([]string{"app-1", "app-2"}))
+
+					Expect(d[1].Name).To(Equal("drain-2"))
+					Expect(d[1].Apps).To(Equal([]string{"My App One"}))
+					Expect(d[1].AppGuids).To(Equal([]string{"app-1"}))
+				}
+			})
+
+			It("requests later pages by page number", func() {
+				_, err := c.Drains("space-guid")
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(curler.URLs).To(ContainElement("/v3/service_instances?page=2&space_guids=space-guid&type=user-provided"))
+				Expect(curler.URLs).To(ContainElement("/v3/service_credential_bindings?page=2&service_instance_guids=guid-1,guid-2&type=app"))
+			})
+
+			It("returns the error if a request fails", func() {
+				key = "/v3/apps?guids=app-2"
+				curler.errs[key] = errors.New("some error")
+
+				_, err := c.Drains("space-guid")
+				Expect(err).To(MatchError("some error"))
+			})
+
+			It("limits the requests in flight across nested batches and pages", func() {
+				counting := &inFlightCurler{c: curler}
+				c = drain.NewServiceDrainLister(
+					counting,
+					drain.WithServiceDrainAppBatchLimit(1),
+					drain.WithServiceDrainConcurrency(2, 0),
+				)
+
+				// Both app batches have pages of their own, which are
+				// requested while the other batch is still in flight.
+				for _, app := range []string{"app-1", "app-2"} {
+					curler.resps["/v3/apps?guids="+app] = appsPageJSON(app, 4)
+					for page := 2; page <= 4; page++ {
+						curler.resps[fmt.Sprintf("/v3/apps?guids=%s&page=%d", app, page)] = appsPageJSON("", 4)
+					}
+				}
+
+				_, err := c.Drains("space-guid")
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(counting.max).To(BeNumerically("<=", 2))
+			})
+
+			It("limits the requests per second", func() {
+				c = drain.NewServiceDrainLister(
+					curler,
+					drain.WithServiceDrainAppBatchLimit(1),
+					drain.WithServiceDrainConcurrency(4, 50),
+				)
+
+				start := time.Now()
+				_, err := c.Drains("space-guid")
+				Expect(err).ToNot(HaveOccurred())
+
+				// 6 requests at 50 per second are spread over at least 100ms.
+				Expect(curler.URLs).To(HaveLen(6))
+				Expect(time.Since(start)).To(BeNumerically(">=", 100*time.Millisecond))
+			})
+		})
+
+		It("returns the error if requesting the service instances fails", func() {
+			key = "/v3/service_instances?space_guids=space-guid&type=user-provided"
+			curler.errs[key] = errors.New("some error")
+
+			_, err := c.Drains("space-guid")
+			Expect(err).To(MatchError("some error"))
+		})
+
+		It("returns the error if requesting the service credential bindings fails", func() {
+			key = "/v3/service_credential_bindings?service_instance_guids=guid-1,guid-2&type=app"
+			curler.errs[key] = errors.New("some error")
+
+			_, err := c.Drains("space-guid")
+			Expect(err).To(MatchError("some error"))
+		})
+
+		It("returns the error if unmarshalling the service credential bindings fails", func() {
+			key = "/v3/service_credential_bindings?service_instance_guids=guid-1,guid-2&type=app"
+			curler.resps[key] = "no json"
+
+			_, err := c.Drains("space-guid")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
 	Describe("TypeFromDrainURL", func() {
 		It("returns default type logs if no query parameters", func() {
 			drainType, _ := c.TypeFromDrainURL("https://papertrail.com")
@@ -217,7 +400,88 @@ var _ = Describe("ListDrainsClient", func() {
 	})
 })
 
+func BenchmarkDrains(b *testing.B) {
+	curler := newCountingCurler(300, 250)
+	c := drain.NewServiceDrainLister(curler)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		d, err := c.Drains("space-guid")
+		if err != nil {
+			b.Fatal(err)
+		}
+		if len(d) != 250 {
+			b.Fatalf("expected 250 drains, got %d", len(d))
+		}
+	}
+
+	b.ReportMetric(float64(atomic.LoadInt64(&curler.requests))/float64(b.N), "requests/op")
+}
+
+// countingCurler serves a space with the given number of user provided
+// service instances, the first drains of which are syslog drains bound to
+// one app each. It counts every request made.
+type countingCurler struct {
+	requests  int64
+	instances []byte
+}
+
+func newCountingCurler(instances, drains int) *countingCurler {
+	var resources []string
+	for i := 0; i < instances; i++ {
+		drainURL := ""
+		if i < drains {
+			drainURL = fmt.Sprintf("syslog://drain-%d.example.com", i)
+		}
+		resources = append(resources, fmt.Sprintf(
+			`{"guid": "instance-%d", "name": "service-%d", "syslog_drain_url": %q}`,
+			i, i, drainURL,
+		))
+	}
+
+	return &countingCurler{
+		instances: []byte(fmt.Sprintf(
+			`{"pagination": {"next": null}, "resources": [%s]}`,
+			strings.Join(resources, ","),
+		)),
+	}
+}
+
+func (s *countingCurler) Curl(URL, method, body string) ([]byte, error) {
+	atomic.AddInt64(&s.requests, 1)
+
+	u, err := url.Parse(URL)
+	if err != nil {
+		return nil, err
+	}
+
+	var resources []string
+	switch u.Path {
+	case "/v3/service_instances":
+		return s.instances, nil
+	case "/v3/service_credential_bindings":
+		for _, g := range strings.Split(u.Query().Get("service_instance_guids"), ",") {
+			resources = append(resources, fmt.Sprintf(
+				`{"relationships": {"app": {"data": {"guid": "app-%[1]s"}}, "service_instance": {"data": {"guid": %[1]q}}}}`,
+				g,
+			))
+		}
+	case "/v3/apps":
+		for _, g := range strings.Split(u.Query().Get("guids"), ",") {
+			resources = append(resources, fmt.Sprintf(`{"guid": %q, "name": "name-%s"}`, g, g))
+		}
+	default:
+		return nil, fmt.Errorf("unhandled endpoint in countingCurler: %s", URL)
+	}
+
+	return []byte(fmt.Sprintf(
+		`{"pagination": {"next": null}, "resources": [%s]}`,
+		strings.Join(resources, ","),
+	)), nil
+}
+
 type stubCurler struct {
+	mu      sync.Mutex
 	URLs    []string
 	methods []string
 	bodies  []string
@@ -235,6 +499,9 @@ func newStubCurler() *stubCurler {
 func (s *stubCurler) Curl(URL, method, body string) ([]byte, error) {
 	URL = strings.Replace(URL, "%2C", ",", -1)
 
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	s.URLs = append(s.URLs, URL)
 	s.methods = append(s.methods, method)
 	s.bodies = append(s.bodies, body)
@@ -251,6 +518,47 @@ func (s *stubCurler) Curl(URL, method, body string) ([]byte, error) {
 	return []byte(resp), s.errs[URL]
 }
 
+// inFlightCurler records the most requests that were in flight at once.
+type inFlightCurler struct {
+	c *stubCurler
+
+	mu       sync.Mutex
+	inFlight int
+	max      int
+}
+
+func (s *inFlightCurler) Curl(URL, method, body string) ([]byte, error) {
+	s.mu.Lock()
+	s.inFlight++
+	if s.inFlight > s.max {
+		s.max = s.inFlight
+	}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		s.inFlight--
+		s.mu.Unlock()
+	}()
+
+	time.Sleep(5 * time.Millisecond)
+	return s.c.Curl(URL, method, body)
+}
+
+// appsPageJSON returns one of totalPages pages of v3 apps. The page holds
+// the given app, or nothing if guid is empty.
+func appsPageJSON(guid string, totalPages int) string {
+	resources := "[]"
+	if guid != "" {
+		resources = fmt.Sprintf(`[{"guid": %q, "name": %q}]`, guid, guid)
+	}
+
+	return fmt.Sprintf(`{
+   "pagination": {"total_results": %d, "total_pages": %d},
+   "resources": %s
+}`, totalPages, totalPages, resources)
+}
+
 var serviceInstancesJSONpage1 = `{
    "total_results": 2,
    "total_pages": 2,
@@ -309,15 +617,15 @@ var serviceInstancesJSON2 = `{
 }`
 
 var serviceBindingsJSON1page1 = `{
-   "total_results": 2,
+   "total_results": 3,
    "total_pages": 2,
    "prev_url": null,
-   "next_url": "/v2/user_provided_service_instances/drain-1/service_bindings&page:2",
+   "next_url": "/v2/service_bindings?q=service_instance_guid+IN+guid-1,guid-2&page=2",
    "resources": [
       {
          "entity": {
             "app_guid": "app-1",
-            "service_instance_guid": "drain-1",
+            "service_instance_guid": "guid-1",
             "syslog_drain_url": "syslog://your-app.cf-app.com",
             "name": null,
             "app_url": "/v2/apps/app-1"
@@ -327,19 +635,28 @@ var serviceBindingsJSON1page1 = `{
 }`
 
 var serviceBindingsJSON1page2 = `{
-   "total_results": 2,
+   "total_results": 3,
    "total_pages": 2,
-   "prev_url": "/v2/user_provided_service_instances/drain-1/service_bindings&page:1",
+   "prev_url": "/v2/service_bindings?q=service_instance_guid+IN+guid-1,guid-2&page=1",
    "next_url": null,
    "resources": [
       {
          "entity": {
             "app_guid": "app-2",
-            "service_instance_guid": "drain-1",
+            "service_instance_guid": "guid-1",
             "syslog_drain_url": "syslog://your-app.cf-app.com",
             "name": null,
             "app_url": "/v2/apps/app-2"
          }
+      },
+      {
+         "entity": {
+            "app_guid": "app-1",
+            "service_instance_guid": "guid-2",
+            "syslog_drain_url": "https://your-app2.cf-app.com?drain-type=metrics",
+            "name": null,
+            "app_url": "/v2/apps/app-1"
+         }
       }
    ]
 }`
@@ -353,7 +670,7 @@ var serviceBindingsJSON2 = `{
       {
          "entity": {
             "app_guid": "app-1",
-            "service_instance_guid": "drain-2",
+            "service_instance_guid": "guid-1",
             "syslog_drain_url": "syslog://your-app.cf-app.com",
             "name": null,
             "app_url": "/v2/apps/app-1"
@@ -362,6 +679,24 @@ var serviceBindingsJSON2 = `{
    ]
 }`
 
+var serviceBindingsJSON4 = `{
+   "total_results": 1,
+   "total_pages": 1,
+   "prev_url": null,
+   "next_url": null,
+   "resources": [
+      {
+         "entity": {
+            "app_guid": "app-2",
+            "service_instance_guid": "guid-2",
+            "syslog_drain_url": "https://your-app2.cf-app.com?drain-type=metrics",
+            "name": null,
+            "app_url": "/v2/apps/app-2"
+         }
+      }
+   ]
+}`
+
 var serviceBindingsJSON3 = `{
    "total_results": 6,
    "total_pages": 1,
@@ -371,7 +706,7 @@ var serviceBindingsJSON3 = `{
       {
          "entity": {
             "app_guid": "app-1",
-            "service_instance_guid": "drain-1",
+            "service_instance_guid": "guid-2",
             "syslog_drain_url": "syslog://your-app.cf-app.com",
             "name": null,
             "app_url": "/v2/apps/app-1"
@@ -380,7 +715,7 @@ var serviceBindingsJSON3 = `{
       {
          "entity": {
             "app_guid": "app-2",
-            "service_instance_guid": "drain-2",
+            "service_instance_guid": "guid-2",
             "syslog_drain_url": "syslog://your-app.cf-app.com",
             "name": null,
             "app_url": "/v2/apps/app-2"
@@ -389,7 +724,7 @@ var serviceBindingsJSON3 = `{
       {
          "entity": {
             "app_guid": "app-3",
-            "service_instance_guid": "drain-3",
+            "service_instance_guid": "guid-2",
             "syslog_drain_url": "syslog://your-app.cf-app.com",
             "name": null,
             "app_url": "/v2/apps/app-3"
@@ -398,7 +733,7 @@ var serviceBindingsJSON3 = `{
       {
          "entity": {
             "app_guid": "app-4",
-            "service_instance_guid": "drain-4",
+            "service_instance_guid": "guid-2",
             "syslog_drain_url": "syslog://your-app.cf-app.com",
             "name": null,
             "app_url": "/v2/apps/app-4"
@@ -407,7 +742,7 @@ var serviceBindingsJSON3 = `{
       {
          "entity": {
             "app_guid": "app-4",
-            "service_instance_guid": "drain-5",
+            "service_instance_guid": "guid-2",
             "syslog_drain_url": "syslog://your-app.cf-app.com",
             "name": null,
             "app_url": "/v2/apps/app-4"
@@ -416,7 +751,7 @@ var serviceBindingsJSON3 = `{
       {
          "entity": {
             "app_guid": "app-4",
-            "service_instance_guid": "drain-6",
+            "service_instance_guid": "guid-2",
             "syslog_drain_url": "syslog://your-app.cf-app.com",
             "name": null,
             "app_url": "/v2/apps/app-4"
@@ -492,3 +827,123 @@ var appJSONpage2 = `{
       }
    ]
 }`
+
+var v3ServiceInstancesJSONpage1 = `{
+   "pagination": {
+      "total_results": 3,
+      "total_pages": 2,
+      "next": {
+         "href": "https://api.example.com/v3/service_instances?page=2&space_guids=space-guid&type=user-provided"
+      },
+      "previous": null
+   },
+   "resources": [
+      {
+         "guid": "guid-1",
+         "name": "drain-1",
+         "type": "user-provided",
+         "syslog_drain_url": "syslog://your-app.cf-app.com"
+      },
+      {
+         "guid": "other-guid",
+         "name": "other-service",
+         "type": "user-provided",
+         "syslog_drain_url": null
+      }
+   ]
+}`
+
+var v3ServiceInstancesJSONpage2 = `{
+   "pagination": {
+      "total_results": 3,
+      "total_pages": 2,
+      "next": null,
+      "previous": {
+         "href": "https://api.example.com/v3/service_instances?page=1&space_guids=space-guid&type=user-provided"
+      }
+   },
+   "resources": [
+      {
+         "guid": "guid-2",
+         "name": "drain-2",
+         "type": "user-provided",
+         "syslog_drain_url": "https://your-app2.cf-app.com?drain-type=metrics"
+      }
+   ]
+}`
+
+var v3ServiceCredentialBindingsJSONpage1 = `{
+   "pagination": {
+      "total_results": 3,
+      "total_pages": 2,
+      "next": {
+         "href": "https://api.example.com/v3/service_credential_bindings?page=2&service_instance_guids=guid-1,guid-2&type=app"
+      }
+   },
+   "resources": [
+      {
+         "guid": "binding-1",
+         "type": "app",
+         "relationships": {
+            "app": {"data": {"guid": "app-1"}},
+            "service_instance": {"data": {"guid": "guid-1"}}
+         }
+      }
+   ]
+}`
+
+var v3ServiceCredentialBindingsJSONpage2 = `{
+   "pagination": {
+      "total_results": 3,
+      "total_pages": 2,
+      "next": null
+   },
+   "resources": [
+      {
+         "guid": "binding-2",
+         "type": "app",
+         "relationships": {
+            "app": {"data": {"guid": "app-2"}},
+            "service_instance": {"data": {"guid": "guid-1"}}
+         }
+      },
+      {
+         "guid": "binding-3",
+         "type": "app",
+         "relationships": {
+            "app": {"data": {"guid": "app-1"}},
+            "service_instance": {"data": {"guid": "guid-2"}}
+         }
+      }
+   ]
+}`
+
+var appJSONcall3 = `{
+   "pagination": {
+      "total_results": 1,
+      "total_pages": 1,
+      "next": null,
+      "previous": null
+   },
+   "resources": [
+      {
+         "guid": "app-1",
+         "name": "My App One"
+      }
+   ]
+}`
+
+var appJSONcall4 = `{
+   "pagination": {
+      "total_results": 1,
+      "total_pages": 1,
+      "next": null,
+      "previous": null
+   },
+   "resources": [
+      {
+         "guid": "app-2",
+         "name": "My App Two"
+      }
+   ]
+}`
@@ -0,0 +1,251 @@
+// Package metrics implements the small subset of Prometheus metrics that the
+// space drain exposes. Metrics are written in the Prometheus text format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Registry holds metrics and writes them in the Prometheus text format.
+type Registry struct {
+	mu      sync.Mutex
+	metrics []*vec
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// NewCounter registers a counter with the given label names.
+func (r *Registry) NewCounter(name, help string, labelNames ...string) *Counter {
+	return &Counter{r.register(name, help, "counter", nil, labelNames)}
+}
+
+// NewGauge registers a gauge with the given label names.
+func (r *Registry) NewGauge(name, help string, labelNames ...string) *Gauge {
+	return &Gauge{r.register(name, help, "gauge", nil, labelNames)}
+}
+
+// NewHistogram registers a histogram with the given upper bucket bounds and
+// label names. The buckets must be sorted.
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labelNames ...string) *Histogram {
+	return &Histogram{r.register(name, help, "histogram", buckets, labelNames)}
+}
+
+func (r *Registry) register(name, help, typ string, buckets []float64, labelNames []string) *vec {
+	v := &vec{
+		name:       name,
+		help:       help,
+		typ:        typ,
+		buckets:    buckets,
+		labelNames: labelNames,
+		series:     make(map[string]*series),
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.metrics = append(r.metrics, v)
+
+	return v
+}
+
+// ServeHTTP writes every metric in the Prometheus text format.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	r.WriteTo(w)
+}
+
+// WriteTo writes every metric in the Prometheus text format.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	metrics := append([]*vec(nil), r.metrics...)
+	r.mu.Unlock()
+
+	var sb strings.Builder
+	for _, m := range metrics {
+		m.write(&sb)
+	}
+
+	n, err := io.WriteString(w, sb.String())
+	return int64(n), err
+}
+
+type Counter struct {
+	v *vec
+}
+
+// Inc adds one to the counter with the given label values.
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds a non-negative value to the counter with the given label values.
+func (c *Counter) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		panic("metrics: counters can not decrease")
+	}
+
+	c.v.update(labelValues, func(s *series) {
+		s.value += delta
+	})
+}
+
+type Gauge struct {
+	v *vec
+}
+
+// Set sets the gauge with the given label values.
+func (g *Gauge) Set(value float64, labelValues ...string) {
+	g.v.update(labelValues, func(s *series) {
+		s.value = value
+	})
+}
+
+type Histogram struct {
+	v *vec
+}
+
+// Observe records a value in the histogram with the given label values.
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	h.v.update(labelValues, func(s *series) {
+		if s.counts == nil {
+			s.counts = make([]uint64, len(h.v.buckets))
+		}
+
+		for i, upper := range h.v.buckets {
+			if value <= upper {
+				s.counts[i]++
+			}
+		}
+		s.value += value
+		s.count++
+	})
+}
+
+// vec is a metric along with its series for each set of label values.
+type vec struct {
+	name       string
+	help       string
+	typ        string
+	buckets    []float64
+	labelNames []string
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+type series struct {
+	labelValues []string
+
+	// value is the counter or gauge value, or the sum of a histogram.
+	value  float64
+	count  uint64
+	counts []uint64
+}
+
+func (v *vec) update(labelValues []string, f func(*series)) {
+	if len(labelValues) != len(v.labelNames) {
+		panic(fmt.Sprintf(
+			"metrics: %s expects %d label values, got %d",
+			v.name,
+			len(v.labelNames),
+			len(labelValues),
+		))
+	}
+
+	key := strings.Join(labelValues, "\xff")
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	s, ok := v.series[key]
+	if !ok {
+		s = &series{labelValues: append([]string(nil), labelValues...)}
+		v.series[key] = s
+	}
+	f(s)
+}
+
+func (v *vec) write(sb *strings.Builder) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	fmt.Fprintf(sb, "# HELP %s %s\n", v.name, helpEscaper.Replace(v.help))
+	fmt.Fprintf(sb, "# TYPE %s %s\n", v.name, v.typ)
+
+	keys := make([]string, 0, len(v.series))
+	for k := range v.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		s := v.series[k]
+		labels := v.labels(s.labelValues)
+
+		if v.typ != "histogram" {
+			fmt.Fprintf(sb, "%s%s %s\n", v.name, labels.String(), formatFloat(s.value))
+			continue
+		}
+
+		for i, upper := range v.buckets {
+			fmt.Fprintf(sb, "%s_bucket%s %d\n", v.name, labels.with("le", formatFloat(upper)).String(), s.counts[i])
+		}
+		fmt.Fprintf(sb, "%s_bucket%s %d\n", v.name, labels.with("le", "+Inf").String(), s.count)
+		fmt.Fprintf(sb, "%s_sum%s %s\n", v.name, labels.String(), formatFloat(s.value))
+		fmt.Fprintf(sb, "%s_count%s %d\n", v.name, labels.String(), s.count)
+	}
+}
+
+type labelSet [][2]string
+
+func (v *vec) labels(values []string) labelSet {
+	ls := make(labelSet, len(values))
+	for i, value := range values {
+		ls[i] = [2]string{v.labelNames[i], value}
+	}
+
+	return ls
+}
+
+func (ls labelSet) with(name, value string) labelSet {
+	return append(append(labelSet(nil), ls...), [2]string{name, value})
+}
+
+func (ls labelSet) String() string {
+	if len(ls) == 0 {
+		return ""
+	}
+
+	pairs := make([]string, len(ls))
+	for i, l := range ls {
+		pairs[i] = fmt.Sprintf(`%s="%s"`, l[0], labelValueEscaper.Replace(l[1]))
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+var (
+	labelValueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+	helpEscaper       = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	case math.IsNaN(f):
+		return "NaN"
+	}
+
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,105 @@
+package metrics_test
+
+import (
+	"bytes"
+	"net/http/httptest"
+
+	"code.cloudfoundry.org/cf-drain-cli/internal/metrics"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Registry", func() {
+	var (
+		r *metrics.Registry
+	)
+
+	BeforeEach(func() {
+		r = metrics.NewRegistry()
+	})
+
+	write := func() string {
+		buf := bytes.NewBuffer(nil)
+		_, err := r.WriteTo(buf)
+		Expect(err).ToNot(HaveOccurred())
+		return buf.String()
+	}
+
+	It("writes counters by label values", func() {
+		c := r.NewCounter("binds_total", "Binds by result.", "result")
+		c.Inc("success")
+		c.Inc("success")
+		c.Add(0.5, "failure")
+
+		Expect(write()).To(Equal(`# HELP binds_total Binds by result.
+# TYPE binds_total counter
+binds_total{result="failure"} 0.5
+binds_total{result="success"} 2
+`))
+	})
+
+	It("writes gauges without labels", func() {
+		g := r.NewGauge("apps_bound", "Apps bound.")
+		g.Set(3)
+		g.Set(7)
+
+		Expect(write()).To(Equal(`# HELP apps_bound Apps bound.
+# TYPE apps_bound gauge
+apps_bound 7
+`))
+	})
+
+	It("writes histograms with cumulative buckets", func() {
+		h := r.NewHistogram("duration_seconds", "Duration.", []float64{0.1, 1}, "endpoint")
+		h.Observe(0.05, "/v3/apps")
+		h.Observe(0.5, "/v3/apps")
+		h.Observe(2, "/v3/apps")
+
+		Expect(write()).To(Equal(`# HELP duration_seconds Duration.
+# TYPE duration_seconds histogram
+duration_seconds_bucket{endpoint="/v3/apps",le="0.1"} 1
+duration_seconds_bucket{endpoint="/v3/apps",le="1"} 2
+duration_seconds_bucket{endpoint="/v3/apps",le="+Inf"} 3
+duration_seconds_sum{endpoint="/v3/apps"} 2.55
+duration_seconds_count{endpoint="/v3/apps"} 3
+`))
+	})
+
+	It("escapes label values and help text", func() {
+		c := r.NewCounter("errors_total", "Errors\nwith \\ newlines.", "error")
+		c.Inc("a \"quoted\"\nvalue\\")
+
+		Expect(write()).To(Equal(`# HELP errors_total Errors\nwith \\ newlines.
+# TYPE errors_total counter
+errors_total{error="a \"quoted\"\nvalue\\"} 1
+`))
+	})
+
+	It("writes metrics in the order they were registered", func() {
+		r.NewGauge("b", "B.")
+		r.NewGauge("a", "A.")
+
+		Expect(write()).To(Equal("# HELP b B.\n# TYPE b gauge\n# HELP a A.\n# TYPE a gauge\n"))
+	})
+
+	It("panics for the wrong number of label values", func() {
+		c := r.NewCounter("binds_total", "Binds.", "result")
+		Expect(func() { c.Inc() }).To(Panic())
+	})
+
+	It("panics if a counter decreases", func() {
+		c := r.NewCounter("binds_total", "Binds.")
+		Expect(func() { c.Add(-1) }).To(Panic())
+	})
+
+	It("serves the metrics over HTTP", func() {
+		r.NewCounter("binds_total", "Binds.").Inc()
+
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+		Expect(rec.Header().Get("Content-Type")).To(Equal("text/plain; version=0.0.4"))
+		Expect(rec.Body.String()).To(ContainSubstring("binds_total 1\n"))
+	})
+})